@@ -3,28 +3,38 @@ package align
 import (
 	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
+	"os"
 	"strings"
-
-	"github.com/mattn/go-runewidth"
+	"sync"
 )
 
 // Justification is used to set the alignment of the column
 // contents itself along the right, left, or center.
 type Justification byte
 
-// Left, Right or Center Justification options.
+// Left, Right, Center or Auto Justification options.
 const (
 	JustifyRight Justification = iota + 1
 	JustifyCenter
 	JustifyLeft
+	JustifyAuto // right for columns of numbers or dates, otherwise left.  See UpdatePadding.
 )
 
 // TextQualifier is used to configure the scanner to account for a text qualifier.
+// With CSV set, qualified fields are parsed according to RFC 4180: a doubled Qualifier
+// within a qualified field is an escaped Qualifier, and a Qualifier that appears partway
+// through an unqualified field is kept as part of its text.
+// With Multiline set, the input is read by record instead of by line, and a qualified field
+// may contain line breaks.  Such a field is output as a cell spanning multiple lines.
+// Fixed-width input and input split with NestOpts are still read by line, as they are not qualified.
 type TextQualifier struct {
 	On        bool
 	Qualifier string
+	CSV       bool
+	Multiline bool
 }
 
 // PaddingOpts provides configurability for left/center/right Justification and padding length.
@@ -63,8 +73,43 @@ type fieldPad struct {
 	bytes.Buffer
 }
 
+const maxInt = int(^uint(0) >> 1)
+
+// ReadError records a failure to scan the input, such as a line longer
+// than the scanner's maximum token size (bufio.ErrTooLong).
+type ReadError struct {
+	Line int // line number that could not be read
+	Err  error
+}
+
+func (e *ReadError) Error() string {
+	return fmt.Sprintf("align: reading line %d: %v", e.Line, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ReadError) Unwrap() error { return e.Err }
+
+// WriteError records a failure to write the aligned output.
+// Line is 0 if the error occurred while flushing the remaining buffered output
+// or writing the end of a table.
+type WriteError struct {
+	Line int // line number being written
+	Err  error
+}
+
+func (e *WriteError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("align: flushing output: %v", e.Err)
+	}
+	return fmt.Sprintf("align: writing line %d: %v", e.Line, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *WriteError) Unwrap() error { return e.Err }
+
 // Align scans input and writes output with aligned text.
 type Align struct {
+	in           io.Reader
 	scanner      *bufio.Scanner
 	writer       *bufio.Writer
 	sep          string // separator string or delimiter
@@ -76,6 +121,35 @@ type Align struct {
 	filterLen    int
 	lines        []string
 	padder       PadGrower
+	twoPass      bool
+	seeker       io.ReadSeeker // set during Align if the input will be read twice
+	offset       int64         // position of the seeker when the first pass started
+	streamOpts   StreamOpts
+	maxLine      int // 0 for the bufio.Scanner default, < 0 for no limit
+	widthOpts    WidthOpts
+	delim        delimiter   // nil if fields are separated by sep
+	keepSeps     bool        // write the separators found in the input
+	sepCounts    map[int]int // widest separator after each column
+	maxSplit     int         // maximum number of column boundaries in a line
+	escOpts      EscapeOpts  // escaped separators within unqualified fields
+	quoting      Quoting     // qualifiers written to the output
+	nestOpts     NestOpts    // brackets and string literals that separators do not split
+	format       Format
+	tableStyle   Style
+	htmlOpts     HTMLOpts
+	lastRow      int // number of the last line written by writeRow
+	headerOpts   HeaderOpts
+	headers      []string           // header lines, kept to be repeated
+	mdHeader     []string           // fields of the header lines joined into the single header row of a Markdown table
+	columnTypes  map[int]columnType // type of the fields of each column, for JustifyAuto
+	tabCounts    map[int][]int      // widest field containing tabs in each column, by its start between tab stops
+	starts       map[int]int        // output column at which each column starts, set by placeTabs
+	spoolAt      int64              // spool threshold in bytes
+	held         int64              // bytes of input held in lines
+	spool        *os.File
+	spoolw       *bufio.Writer
+	closed       bool       // set by Close, after which no spool is created
+	spoolMu      sync.Mutex // guards spool and closed
 }
 
 // NewAlign creates and initializes a ScanWriter with in and out as its initial Reader and Writer
@@ -84,20 +158,27 @@ type Align struct {
 // and output the results in an aligned format.
 // Left Justification is used by default.  See UpdatePadding to set the Justification.
 func NewAlign(in io.Reader, out io.Writer, sep string, qu TextQualifier) *Align {
-	return &Align{
-		scanner:      bufio.NewScanner(in),
+	a := &Align{
+		in:           in,
 		writer:       bufio.NewWriter(out),
 		sep:          sep,
 		sepOut:       sep,
 		columnCounts: make(map[int]int),
+		sepCounts:    make(map[int]int),
+		columnTypes:  make(map[int]columnType),
+		tabCounts:    make(map[int][]int),
 		txtq:         qu,
 		padOpts: PaddingOpts{
 			//defaults
 			Justification: JustifyLeft,
 			Pad:           1,
 		},
-		padder: &fieldPad{}, // default; set with UpdatePadder()
+		tableStyle: StyleASCII,
+		padder:     &fieldPad{}, // default; set with UpdatePadder()
 	}
+	a.scanner = a.newScanner(in)
+
+	return a
 }
 
 // OutputSep sets the output separator string with outsep if a different value from the input sep is desired.
@@ -107,9 +188,89 @@ func (a *Align) OutputSep(outsep string) {
 
 // Align determines the length of each field of text around the configured delimiter and aligns all of the
 // text by the delimiter.
-func (a *Align) Align() {
-	a.columnLength()
-	a.export()
+// If streaming is enabled with UpdateStream, the lines are written as they are read instead.
+// The returned error is a *ReadError if the input could not be scanned, or a *WriteError
+// if the output could not be written.
+func (a *Align) Align() error {
+	defer a.removeSpool()
+
+	if a.streamOpts.Lines > 0 {
+		return a.stream()
+	}
+
+	a.seeker = nil
+	if a.twoPass {
+		if rs, ok := a.in.(io.ReadSeeker); ok {
+			// pipes and terminals may implement io.Seeker without being able to seek
+			if off, err := rs.Seek(0, io.SeekCurrent); err == nil {
+				a.seeker, a.offset = rs, off
+			}
+		}
+	}
+
+	if err := a.columnLength(); err != nil {
+		return err
+	}
+	if a.inferColumns() {
+		err := a.eachLine(func(lineNum int, line string) error {
+			a.measure(lineNum, line)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	a.placeTabs()
+	return a.export()
+}
+
+// MaxLineLength sets the maximum length in bytes of a line of input, excluding its newline.
+// The default is bufio.MaxScanTokenSize, and Align returns a *ReadError wrapping
+// bufio.ErrTooLong for the first line that exceeds it.
+// If n is 0 or less, lines of any length are read, and memory use grows with the longest line.
+// It must be called before Align.
+func (a *Align) MaxLineLength(n int) {
+	if n <= 0 {
+		n = -1
+	}
+	a.maxLine = n
+	a.scanner = a.newScanner(a.in)
+}
+
+// newScanner returns a line scanner for r that accepts lines up to the maximum line length.
+// If multiline fields are enabled, it scans records instead.
+func (a *Align) newScanner(r io.Reader) *bufio.Scanner {
+	s := bufio.NewScanner(r)
+	if a.txtq.On && a.txtq.Multiline {
+		s.Split(a.scanRecords)
+	}
+	switch {
+	case a.maxLine > 0:
+		s.Buffer(nil, a.maxLine+1)
+	case a.maxLine < 0:
+		s.Buffer(nil, maxInt)
+	}
+	return s
+}
+
+// readError records the failure to scan line lineNum.
+func (a *Align) readError(lineNum int, err error) error {
+	if errors.Is(err, bufio.ErrTooLong) {
+		max := a.maxLine
+		if max == 0 {
+			max = bufio.MaxScanTokenSize
+		}
+		err = fmt.Errorf("line exceeds the maximum length of %d bytes: %w", max, err)
+	}
+	return &ReadError{Line: lineNum, Err: err}
+}
+
+// TwoPass sets whether Align should read a seekable input twice instead of
+// holding all of its lines in memory between measuring and writing them.
+// Memory use then depends on the number of columns rather than the size of the input.
+// If the input does not implement io.ReadSeeker or cannot seek, its lines are buffered as usual.
+func (a *Align) TwoPass(on bool) {
+	a.twoPass = on
 }
 
 // columnSize looks up the Align's columnCounts key with num and returns the value
@@ -123,6 +284,9 @@ func (a *Align) columnSize(num int) int {
 }
 
 // UpdatePadding uses PaddingOpts p to update the Align's padding options.
+// Columns with JustifyAuto are classified by their fields, ignoring the header lines set by UpdateHeader
+// and empty fields.  A column of integers, decimal numbers or dates is right justified, and any other
+// column is left justified.  A Justification other than JustifyAuto in the ColumnOverride still applies.
 func (a *Align) UpdatePadding(p PaddingOpts) {
 	a.padOpts = p
 }
@@ -152,7 +316,12 @@ func genFieldLen(s, sep, qual string) int {
 	if len(qual) > 0 && strings.HasPrefix(s, qual) {
 		endIdx += len(qual)
 
-		endIdx += strings.Index(s[endIdx:], qual) + len(qual)
+		closeIdx := strings.Index(s[endIdx:], qual)
+		if closeIdx == -1 {
+			// unterminated qualifier; the field runs to the end of s
+			return len(s)
+		}
+		endIdx += closeIdx + len(qual)
 
 		return len(s[:endIdx])
 	}
@@ -167,48 +336,155 @@ func genFieldLen(s, sep, qual string) int {
 	return len(s[:endIdx])
 }
 
-// columnLength scans the input and determines the maximum length of each field based on
-// the longest value for each field in all of the pertaining lines.
-// All of the lines of the io.Reader are returned as a string slice.
-func (a *Align) columnLength() {
+// csvFieldLen works in the same way as genFieldLen, but follows RFC 4180 for qualified fields.
+// A doubled qual inside a qualified field does not end the field, and any text between the
+// closing qual and the next sep is kept with the field.
+// If s does not begin with qual, any qual within the field is treated as text.
+func csvFieldLen(s, sep, qual string) int {
+	endIdx := qualifiedLen(s, qual, true)
+	if endIdx == 0 {
+		return genFieldLen(s, sep, "")
+	}
+
+	if sepIdx := strings.Index(s[endIdx:], sep); sepIdx != -1 {
+		return endIdx + sepIdx
+	}
+	return len(s)
+}
+
+// qualifiedFieldLen returns the length of the first field of s, parsing
+// qualified fields as configured by the Align's TextQualifier and escaped
+// separators as configured by its EscapeOpts.
+func (a *Align) qualifiedFieldLen(s, sep, qual string) int {
+	if a.escOpts.Escape != "" && (qual == "" || !strings.HasPrefix(s, qual)) {
+		return escapedFieldLen(s, sep, a.escOpts.Escape)
+	}
+	if a.txtq.CSV {
+		return csvFieldLen(s, sep, qual)
+	}
+	return genFieldLen(s, sep, qual)
+}
+
+// columnLength scans the input and determines the maximum display width of each field based on
+// the widest value for each field in all of the pertaining lines.
+// All of the lines of the io.Reader are stored in the Align's lines, unless
+// the input will be read again by export or the lines are spooled to a temporary file.
+func (a *Align) columnLength() error {
 	a.lines = make([]string, 0)
+	a.held = 0
+	a.spoolw = nil
 
+	var lineNum int
 	for a.scanner.Scan() {
-		var columnNum int
-		var temp int
-
+		lineNum++
 		line := a.scanner.Text()
 
-		if a.txtq.On {
-			for start := 0; start < len(line); {
-				temp = fieldLenEscaped(line[start:], a.sep, a.txtq.Qualifier)
-				start += temp + len(a.sep)
-				if temp > a.columnCounts[columnNum] {
-					a.columnCounts[columnNum] = temp
-				}
-				columnNum++
-				temp = 0
+		a.measure(lineNum, line)
+
+		if a.seeker == nil {
+			if err := a.hold(lineNum, line); err != nil {
+				return err
 			}
-		} else {
-			for start := 0; start < len(line); {
-				temp = fieldLen(line[start:], a.sep)
-				start += temp + len(a.sep)
-				if temp > a.columnCounts[columnNum] {
-					a.columnCounts[columnNum] = temp
-				}
-				columnNum++
-				temp = 0
+		}
+	}
+
+	if err := a.scanner.Err(); err != nil {
+		return a.readError(lineNum+1, err)
+	}
+	return a.finishSpool()
+}
+
+// measure updates the Align's column counts with the display width of each field in the line numbered lineNum,
+// and the type of each field for JustifyAuto.
+// The width of a field spanning multiple lines is the width of its longest line.
+func (a *Align) measure(lineNum int, line string) {
+	if f, ok := a.delim.(*fixedWidth); ok && f.infer {
+		f.observe(line, a.widthOpts)
+		return
+	}
+
+	words, seps := a.fields(line)
+	words = a.markdownHeader(lineNum, words)
+	for columnNum, word := range words {
+		a.measureField(columnNum, word)
+	}
+	if a.autoJustify() && !a.isHeader(lineNum) {
+		a.classify(line)
+	}
+
+	if a.keepSeps {
+		for columnNum, sep := range seps {
+			if temp := a.widthOpts.width(strings.TrimSpace(sep)); temp > a.sepCounts[columnNum] {
+				a.sepCounts[columnNum] = temp
 			}
 		}
+	}
+}
 
-		a.lines = append(a.lines, line)
+// rewind prepares a seekable input or the spooled lines to be scanned again from where the first pass started.
+func (a *Align) rewind() error {
+	if _, err := a.seeker.Seek(a.offset, io.SeekStart); err != nil {
+		return &ReadError{Line: 1, Err: err}
 	}
+	a.scanner = a.newScanner(a.seeker)
+	return nil
 }
 
 const padchar byte = ' '
 
 // export will pad each field in lines based on the Align's column counts.
-func (a *Align) export() {
+// If the input is seekable and two pass mode is enabled, or the lines were spooled
+// to a temporary file, the lines are scanned again instead.
+func (a *Align) export() error {
+	surroundingPad := a.surroundingPad()
+
+	err := a.eachLine(func(lineNum int, line string) error {
+		return a.exportLine(lineNum, line, surroundingPad)
+	})
+	if err != nil {
+		return err
+	}
+	if err := a.finishRows(); err != nil {
+		return err
+	}
+
+	if err := a.writer.Flush(); err != nil {
+		return &WriteError{Err: err}
+	}
+	return nil
+}
+
+// eachLine calls fn for each of the lines read by columnLength, scanning them again if the input
+// is seekable and two pass mode is enabled, or the lines were spooled to a temporary file.
+func (a *Align) eachLine(fn func(lineNum int, line string) error) error {
+	if a.seeker == nil {
+		for i, line := range a.lines {
+			if err := fn(i+1, line); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := a.rewind(); err != nil {
+		return err
+	}
+
+	var lineNum int
+	for a.scanner.Scan() {
+		lineNum++
+		if err := fn(lineNum, a.scanner.Text()); err != nil {
+			return err
+		}
+	}
+	if err := a.scanner.Err(); err != nil {
+		return a.readError(lineNum+1, err)
+	}
+	return nil
+}
+
+// surroundingPad returns the padding written on either side of the output separator.
+func (a *Align) surroundingPad() string {
 	if a.padOpts.Pad < 0 {
 		a.padOpts.Pad = 0
 	}
@@ -217,54 +493,112 @@ func (a *Align) export() {
 	for i := 0; i < a.padOpts.Pad; i++ {
 		surroundingPad = append(surroundingPad, padchar)
 	}
+	return string(surroundingPad)
+}
 
-	for _, line := range a.lines {
-		words := a.splitWithQual(line, a.sep, a.txtq.Qualifier)
+// exportLine pads each field of line based on the Align's column counts and writes it.
+// If any field spans multiple lines, the other fields are padded with blank lines to match.
+func (a *Align) exportLine(lineNum int, line, surroundingPad string) error {
+	if err := a.writeHeader(lineNum, line, surroundingPad); err != nil {
+		return err
+	}
 
-		var columnNum int
-		var tempColumn int // used for call to pad() to incorporate column filtering
-		for _, word := range words {
-			if a.filterLen > 0 {
-				if !contains(a.filter, columnNum+1) {
-					columnNum++
-					if columnNum == len(words) {
-						a.writer.WriteString("\n")
-					}
-					continue
-				}
+	words, seps := a.fields(line)
+	words = a.markdownHeader(lineNum, words)
+
+	if !strings.Contains(line, "\n") {
+		if err := a.writeWords(lineNum, words, seps, surroundingPad); err != nil {
+			return err
+		}
+		return a.writeHeaderRule(lineNum, surroundingPad)
+	}
+
+	var height int
+	cells := make([][]string, len(words))
+	for i, word := range words {
+		cells[i] = cellLines(word)
+		if len(cells[i]) > height {
+			height = len(cells[i])
+		}
+	}
+
+	row := make([]string, len(words))
+	for r := 0; r < height; r++ {
+		for i, cell := range cells {
+			row[i] = ""
+			if r < len(cell) {
+				row[i] = cell[r]
 			}
+		}
+		if err := a.writeWords(lineNum, row, seps, surroundingPad); err != nil {
+			return err
+		}
+	}
+	return a.writeHeaderRule(lineNum, surroundingPad)
+}
 
-			j := a.padOpts.Justification
+// writeWords pads each of words based on the Align's column counts and writes them as one line,
+// separated by the output separator or by seps if the Align keeps its separators.
+func (a *Align) writeWords(lineNum int, words, seps []string, surroundingPad string) error {
+	if a.format > FormatText {
+		return a.writeRow(lineNum, words)
+	}
 
-			// override Justification for the specified columnNum in the key for the PaddingOpts.columnOverride map
-			if len(a.padOpts.ColumnOverride) > 0 {
-				for k, v := range a.padOpts.ColumnOverride {
-					if k == columnNum+1 {
-						j = v
+	var columnNum int
+	var tempColumn int // used for call to pad() to incorporate column filtering
+	for _, word := range words {
+		if a.filterLen > 0 {
+			if !contains(a.filter, columnNum+1) {
+				columnNum++
+				if columnNum == len(words) {
+					if err := a.writer.WriteByte('\n'); err != nil {
+						return &WriteError{Line: lineNum, Err: err}
 					}
 				}
+				continue
 			}
+		}
 
-			padLength := countPadding(word, a.columnCounts[columnNum])
-			paddedWord := applyPadding(a.padder, word, string(surroundingPad), tempColumn, padLength, j)
+		j := a.lineJustification(lineNum, columnNum)
+		word = a.prepare(word, columnNum)
 
-			a.padder.Reset() // empty the buffer for the next iteration.
+		word, padLength, j := a.fit(word, columnNum, a.columnCounts[columnNum], j)
 
-			columnNum++
-			tempColumn++
+		var paddedWord []byte
+		if _, ok := a.delim.(sequence); ok {
+			// each column boundary has its own padding
+			if tempColumn > 0 {
+				a.padder.WriteString(a.boundaryPad(columnNum - 1))
+			}
+			applyPadding(a.padder, word, "", tempColumn, padLength, j)
+			a.padder.WriteString(a.boundaryPad(columnNum))
+			paddedWord = a.padder.Bytes()
+		} else {
+			paddedWord = applyPadding(a.padder, word, surroundingPad, tempColumn, padLength, j)
+		}
+
+		a.padder.Reset() // empty the buffer for the next iteration.
 
-			// Do not add a delimiter to the last field
-			// This also properly aligns the output even if there are lines with a different number of fields
-			if a.filterLen > 0 && a.filter[a.filterLen-1] == columnNum || columnNum == len(words) {
-				a.writer.Write(paddedWord)
-				a.writer.WriteByte('\n')
-				break
+		columnNum++
+		tempColumn++
+
+		if _, err := a.writer.Write(paddedWord); err != nil {
+			return &WriteError{Line: lineNum, Err: err}
+		}
+
+		// Do not add a delimiter to the last field
+		// This also properly aligns the output even if there are lines with a different number of fields
+		if a.filterLen > 0 && a.filter[a.filterLen-1] == columnNum || columnNum == len(words) {
+			if err := a.writer.WriteByte('\n'); err != nil {
+				return &WriteError{Line: lineNum, Err: err}
 			}
-			a.writer.Write(paddedWord)
-			a.writer.WriteString(a.sepOut)
+			break
+		}
+		if _, err := a.writer.WriteString(a.outputSep(columnNum-1, seps)); err != nil {
+			return &WriteError{Line: lineNum, Err: err}
 		}
 	}
-	a.writer.Flush()
+	return nil
 }
 
 func fillWithPadding(padder Padder, length int) {
@@ -310,14 +644,9 @@ func applyPadding(padder Padder, original, surroundingPad string, columnNum, pad
 	return padder.Bytes()
 }
 
-// determines the length of the padding needed.
-func countPadding(s string, count int) int {
-	padLength := count - len(s)
-	rCount, wordLen := runewidth.StringWidth(s), len(s)
-	if rCount < wordLen {
-		padLength += wordLen - rCount
-	}
-	return padLength
+// determines the length of the padding needed to fill count cells.
+func countPadding(s string, count int, w WidthOpts) int {
+	return count - w.width(s)
 }
 
 // prepends padding.
@@ -335,21 +664,42 @@ func trailingPad(sb *strings.Builder, padLen int) {
 }
 
 // splitWithQual basically works like the standard strings.Split() func, but will consider a text qualifier if set.
+// Only the first maxSplit separators are considered, if set.
 func (a *Align) splitWithQual(s, sep, qual string) []string {
 	if !a.txtq.On {
-		return strings.Split(s, sep) // use standard Split() method if no qualifier is considered
+		if a.escOpts.Escape == "" {
+			if a.maxSplit > 0 {
+				return strings.SplitN(s, sep, a.maxSplit+1)
+			}
+			return strings.Split(s, sep) // use standard Split() method if no qualifier is considered
+		}
+		qual = ""
 	}
 	var words = make([]string, 0, strings.Count(s, sep))
 
 	for start := 0; start <= len(s); {
-		count := genFieldLen(s[start:], sep, qual)
-		words = append(words, s[start:start+count])
+		if a.maxSplit > 0 && len(words) == a.maxSplit {
+			words = append(words, a.stripEscapes(s[start:], sep, qual))
+			break
+		}
+
+		count := a.qualifiedFieldLen(s[start:], sep, qual)
+		words = append(words, a.stripEscapes(s[start:start+count], sep, qual))
 		start += count + len(sep)
 	}
 
 	return words
 }
 
+// stripEscapes removes the escapes from the escaped separators in an unqualified word
+// if configured by the Align's EscapeOpts.
+func (a *Align) stripEscapes(word, sep, qual string) string {
+	if !a.escOpts.Strip || a.escOpts.Escape == "" || qual != "" && strings.HasPrefix(word, qual) {
+		return word
+	}
+	return unescape(word, sep, a.escOpts.Escape)
+}
+
 // FilterColumns sets which column numbers should be output.
 func (a *Align) FilterColumns(c []int) {
 	a.filter = c
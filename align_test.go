@@ -1,7 +1,9 @@
 package align
 
 import (
+	"bufio"
 	"bytes"
+	"errors"
 	"io"
 	"os"
 	"strings"
@@ -80,7 +82,7 @@ var columnCountCases = []struct {
 		"",
 		map[int]int{
 			0: 5,
-			1: 5,
+			1: 4,
 		},
 	},
 }
@@ -467,7 +469,7 @@ func TestSplit(t *testing.T) {
 // TestPad
 func TestPad(t *testing.T) {
 	for _, tt := range paddingCases {
-		padLen := countPadding(tt.input, tt.columnCount)
+		padLen := countPadding(tt.input, tt.columnCount, WidthOpts{})
 		got := applyPadding(tt.pad, tt.input, " ", 1, padLen, tt.po.Justification)
 
 		if len(got) != tt.expected {
@@ -512,7 +514,7 @@ func TestFieldLen(t *testing.T) {
 // TestCountPadding
 func TestCountPadding(t *testing.T) {
 	for _, tt := range countPaddingCases {
-		got := countPadding(tt.input, tt.fieldLen)
+		got := countPadding(tt.input, tt.fieldLen, WidthOpts{})
 		if got != tt.expected {
 			t.Fatalf("countPadding(%v) = %v; want %v", tt.input, got, tt.expected)
 		}
@@ -694,6 +696,15 @@ func Test_genFieldLen(t *testing.T) {
 			},
 			want: 7,
 		},
+		{
+			name: "Unterminated qualifier",
+			args: args{
+				s:    "\"abc, def",
+				sep:  ",",
+				qual: "\"",
+			},
+			want: 9,
+		},
 		{
 			name: "No qualifier not last field",
 			args: args{
@@ -723,3 +734,305 @@ func BenchmarkGenFieldLen(b *testing.B) {
 		genFieldLen(s, ",", "\"")
 	}
 }
+
+// errWriter fails every write after n bytes have been written.
+type errWriter struct {
+	n int
+}
+
+var errDiskFull = errors.New("disk full")
+
+func (w *errWriter) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		written := w.n
+		w.n = 0
+		return written, errDiskFull
+	}
+	w.n -= len(p)
+	return len(p), nil
+}
+
+func TestAlignReadError(t *testing.T) {
+	input := "a,b\n" + strings.Repeat("x", bufio.MaxScanTokenSize+1) + "\nc,d\n"
+
+	a := NewAlign(strings.NewReader(input), &bytes.Buffer{}, comma, TextQualifier{})
+	err := a.Align()
+
+	var rerr *ReadError
+	if !errors.As(err, &rerr) {
+		t.Fatalf("Align() = %v; want *ReadError", err)
+	}
+	if rerr.Line != 2 {
+		t.Fatalf("ReadError.Line = %v; want %v", rerr.Line, 2)
+	}
+	if !errors.Is(err, bufio.ErrTooLong) {
+		t.Fatalf("Align() = %v; want %v", err, bufio.ErrTooLong)
+	}
+}
+
+func TestAlignWriteError(t *testing.T) {
+	var lines []string
+	for i := 0; i < 1000; i++ {
+		lines = append(lines, "first,middle,last")
+	}
+
+	a := NewAlign(strings.NewReader(strings.Join(lines, "\n")), &errWriter{n: 10}, comma, TextQualifier{})
+	err := a.Align()
+
+	var werr *WriteError
+	if !errors.As(err, &werr) {
+		t.Fatalf("Align() = %v; want *WriteError", err)
+	}
+	if werr.Line < 1 {
+		t.Fatalf("WriteError.Line = %v; want > 0", werr.Line)
+	}
+	if !errors.Is(err, errDiskFull) {
+		t.Fatalf("Align() = %v; want %v", err, errDiskFull)
+	}
+}
+
+func TestAlignFlushError(t *testing.T) {
+	a := NewAlign(strings.NewReader("first,last"), &errWriter{}, comma, TextQualifier{})
+	err := a.Align()
+
+	var werr *WriteError
+	if !errors.As(err, &werr) || werr.Line != 0 {
+		t.Fatalf("Align() = %v; want *WriteError from Flush", err)
+	}
+}
+
+// onlyReader hides any other interfaces implemented by the underlying io.Reader.
+type onlyReader struct {
+	io.Reader
+}
+
+var twoPassCases = []struct {
+	input    io.Reader
+	seekable bool
+}{
+	{
+		strings.NewReader("first,middle,last\nJohn,,Doe\n\"Henry, Jr.\",M,Mellencamp"),
+		true,
+	},
+	{
+		onlyReader{strings.NewReader("first,middle,last\nJohn,,Doe\n\"Henry, Jr.\",M,Mellencamp")},
+		false,
+	},
+}
+
+func TestTwoPass(t *testing.T) {
+	expected := `first        , middle , last       
+John         ,        , Doe        
+"Henry, Jr." , M      , Mellencamp 
+`
+
+	for _, tt := range twoPassCases {
+		out := &bytes.Buffer{}
+		a := NewAlign(tt.input, out, comma, TextQualifier{On: true, Qualifier: `"`})
+		a.TwoPass(true)
+
+		if err := a.Align(); err != nil {
+			t.Fatalf("Align() = %v; want nil", err)
+		}
+		if got := out.String(); got != expected {
+			t.Fatalf("Align() = \n%v; want\n%v", got, expected)
+		}
+		if tt.seekable && len(a.lines) > 0 {
+			t.Fatalf("TwoPass(true) buffered %v lines; want 0", len(a.lines))
+		}
+		if !tt.seekable && len(a.lines) != 3 {
+			t.Fatalf("TwoPass(true) buffered %v lines; want 3", len(a.lines))
+		}
+	}
+}
+
+func TestTwoPassOffset(t *testing.T) {
+	r := strings.NewReader("skipped,line\nfirst,last\nJohn,Doe")
+	r.Seek(13, io.SeekStart)
+
+	out := &bytes.Buffer{}
+	a := NewAlign(r, out, comma, TextQualifier{})
+	a.TwoPass(true)
+
+	if err := a.Align(); err != nil {
+		t.Fatalf("Align() = %v; want nil", err)
+	}
+
+	expected := "first , last \nJohn  , Doe  \n"
+	if got := out.String(); got != expected {
+		t.Fatalf("Align() = %q; want %q", got, expected)
+	}
+}
+
+var maxLineLengthCases = []struct {
+	max       int
+	lineLen   int
+	shouldErr bool
+}{
+	{
+		10,
+		10,
+		false,
+	},
+	{
+		10,
+		11,
+		true,
+	},
+	{
+		0,
+		bufio.MaxScanTokenSize * 4,
+		false,
+	},
+	{
+		-1,
+		bufio.MaxScanTokenSize * 4,
+		false,
+	},
+	{
+		bufio.MaxScanTokenSize * 2,
+		bufio.MaxScanTokenSize + 1,
+		false,
+	},
+}
+
+func TestMaxLineLength(t *testing.T) {
+	for _, tt := range maxLineLengthCases {
+		input := "a,b\n" + strings.Repeat("x", tt.lineLen) + "\nc,d\n"
+
+		out := &bytes.Buffer{}
+		a := NewAlign(strings.NewReader(input), out, comma, TextQualifier{})
+		a.MaxLineLength(tt.max)
+		err := a.Align()
+
+		if tt.shouldErr {
+			var rerr *ReadError
+			if !errors.As(err, &rerr) || !errors.Is(err, bufio.ErrTooLong) || rerr.Line != 2 {
+				t.Fatalf("MaxLineLength(%v) with line of %v bytes: Align() = %v; want *ReadError for line 2", tt.max, tt.lineLen, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("MaxLineLength(%v) with line of %v bytes: Align() = %v; want nil", tt.max, tt.lineLen, err)
+		}
+		if got := strings.Count(out.String(), "\n"); got != 3 {
+			t.Fatalf("MaxLineLength(%v) with line of %v bytes: wrote %v lines; want 3", tt.max, tt.lineLen, got)
+		}
+	}
+}
+
+func Test_csvFieldLen(t *testing.T) {
+	type args struct {
+		s    string
+		sep  string
+		qual string
+	}
+	tests := []struct {
+		name string
+		args args
+		want int
+	}{
+		{
+			name: "Unqualified",
+			args: args{
+				s:    "John,Doe",
+				sep:  ",",
+				qual: "\"",
+			},
+			want: 4,
+		},
+		{
+			name: "Doubled qualifier",
+			args: args{
+				s:    "\"He said \"\"hi\"\", then left\",next",
+				sep:  ",",
+				qual: "\"",
+			},
+			want: 27,
+		},
+		{
+			name: "Only an escaped qualifier",
+			args: args{
+				s:    "\"\"\"\",next",
+				sep:  ",",
+				qual: "\"",
+			},
+			want: 4,
+		},
+		{
+			name: "Empty qualified field",
+			args: args{
+				s:    "\"\",next",
+				sep:  ",",
+				qual: "\"",
+			},
+			want: 2,
+		},
+		{
+			name: "Qualifier partway through field",
+			args: args{
+				s:    "5\" pipe, \"steel\"",
+				sep:  ",",
+				qual: "\"",
+			},
+			want: 7,
+		},
+		{
+			name: "Text after closing qualifier",
+			args: args{
+				s:    "\"abc\"def,next",
+				sep:  ",",
+				qual: "\"",
+			},
+			want: 8,
+		},
+		{
+			name: "Unterminated qualifier",
+			args: args{
+				s:    "\"abc, \"\"def",
+				sep:  ",",
+				qual: "\"",
+			},
+			want: 11,
+		},
+		{
+			name: "Multi-byte separator",
+			args: args{
+				s:    "\"a||\"\"b\"||c",
+				sep:  "||",
+				qual: "\"",
+			},
+			want: 8,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := csvFieldLen(tt.args.s, tt.args.sep, tt.args.qual); got != tt.want {
+				t.Errorf("csvFieldLen() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportCSV(t *testing.T) {
+	input := `id,quote,size
+1,"He said ""hi"", then left",5" pipe
+2,"",
+`
+
+	out := &bytes.Buffer{}
+
+	a := NewAlign(strings.NewReader(input), out, comma, TextQualifier{On: true, Qualifier: `"`, CSV: true})
+	if err := a.Align(); err != nil {
+		t.Fatalf("Align() = %v; want nil", err)
+	}
+
+	expected := `id , quote                       , size    
+1  , "He said ""hi"", then left" , 5" pipe 
+2  , ""                          ,         
+`
+
+	if got := out.String(); got != expected {
+		t.Fatalf("export() = \n%v; want\n%v", got, expected)
+	}
+}
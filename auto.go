@@ -0,0 +1,104 @@
+package align
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// columnType is the type of the fields of a column, for JustifyAuto.
+type columnType byte
+
+// column types, from the narrowest to the widest
+const (
+	typeNone columnType = iota // no fields yet
+	typeInt
+	typeDecimal
+	typeDate
+	typeText
+)
+
+var (
+	intPattern     = regexp.MustCompile(`^[-+]?(\d+|\d{1,3}(,\d{3})+)$`)
+	decimalPattern = regexp.MustCompile(`^[-+]?(\d+|\d{1,3}(,\d{3})+)?(\.\d+)?([eE][-+]?\d+)?$`)
+)
+
+// dateLayouts are the layouts of the fields classified as dates.
+var dateLayouts = []string{
+	"2006-01-02",
+	"2006/01/02",
+	"1/2/2006",
+	"2.1.2006",
+	"2006-01-02 15:04",
+	"2006-01-02 15:04:05",
+	time.RFC3339,
+	time.RFC3339Nano,
+}
+
+// autoJustify reports whether any column may be justified by JustifyAuto.
+func (a *Align) autoJustify() bool {
+	if a.padOpts.Justification == JustifyAuto || a.headerOpts.Justification == JustifyAuto {
+		return true
+	}
+	for _, j := range a.padOpts.ColumnOverride {
+		if j == JustifyAuto {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve returns the Justification of the column numbered columnNum for j,
+// which is only different if j is JustifyAuto.
+func (a *Align) resolve(j Justification, columnNum int) Justification {
+	if j != JustifyAuto {
+		return j
+	}
+
+	switch a.columnTypes[columnNum] {
+	case typeInt, typeDecimal, typeDate:
+		return JustifyRight
+	}
+	return JustifyLeft
+}
+
+// classify updates the type of each column with the type of its field in line.  The fields are classified
+// as they are in the input, before they are qualified or escaped for the output.
+func (a *Align) classify(line string) {
+	words, _ := a.split(line)
+	for columnNum, word := range words {
+		if a.txtq.On {
+			word = unquote(word, a.txtq.Qualifier, a.txtq.CSV)
+		}
+		word = strings.TrimSpace(word)
+		if word == "" {
+			continue
+		}
+
+		t := fieldType(word)
+		switch prev := a.columnTypes[columnNum]; {
+		case prev == typeNone || prev == t:
+		case prev <= typeDecimal && t <= typeDecimal:
+			t = typeDecimal
+		default:
+			t = typeText
+		}
+		a.columnTypes[columnNum] = t
+	}
+}
+
+// fieldType returns the type of the non-empty field s.
+func fieldType(s string) columnType {
+	if intPattern.MatchString(s) {
+		return typeInt
+	}
+	if strings.ContainsAny(s, "0123456789") && decimalPattern.MatchString(s) {
+		return typeDecimal
+	}
+	for _, layout := range dateLayouts {
+		if _, err := time.Parse(layout, s); err == nil {
+			return typeDate
+		}
+	}
+	return typeText
+}
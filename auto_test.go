@@ -0,0 +1,100 @@
+package align
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+var fieldTypeCases = []struct {
+	input    string
+	expected columnType
+}{
+	{"42", typeInt},
+	{"-7", typeInt},
+	{"1,234,567", typeInt},
+	{"3.14", typeDecimal},
+	{"-.5", typeDecimal},
+	{"1,234.50", typeDecimal},
+	{"6.02e23", typeDecimal},
+	{"2024-03-01", typeDate},
+	{"3/1/2024", typeDate},
+	{"2024-03-01T10:00:00Z", typeDate},
+	{"2024-03-01 10:00", typeDate},
+	{"1,23", typeText},
+	{"-", typeText},
+	{".", typeText},
+	{"abc", typeText},
+	{"12 apples", typeText},
+}
+
+func TestFieldType(t *testing.T) {
+	for _, tt := range fieldTypeCases {
+		if got := fieldType(tt.input); got != tt.expected {
+			t.Fatalf("fieldType(%q) = %v; want %v", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestJustifyAuto(t *testing.T) {
+	input := `name,qty,price,date,code
+apple,10,1.5,2024-03-01,A1
+kiwi,,12,2024-12-31,7
+"fig",300,0.25,,B`
+
+	out := &bytes.Buffer{}
+	a := NewAlign(strings.NewReader(input), out, ",", TextQualifier{On: true, Qualifier: `"`})
+	a.UpdatePadding(PaddingOpts{Justification: JustifyAuto, ColumnOverride: map[int]Justification{3: JustifyCenter}, Pad: 1})
+	a.UpdateHeader(HeaderOpts{Lines: 1})
+
+	if err := a.Align(); err != nil {
+		t.Fatalf("Align() = %v; want nil", err)
+	}
+
+	expected := `name  , qty , price ,       date , code 
+apple ,  10 , 1.5   , 2024-03-01 , A1   
+kiwi  ,     ,   12  , 2024-12-31 , 7    
+"fig" , 300 , 0.25  ,            , B    
+`
+	if got := out.String(); got != expected {
+		t.Fatalf("Align() = \n%v; want\n%v", got, expected)
+	}
+}
+
+func TestJustifyAutoOverride(t *testing.T) {
+	input := "a,1\nb,2"
+
+	out := &bytes.Buffer{}
+	a := NewAlign(strings.NewReader(input), out, ",", TextQualifier{})
+	a.UpdatePadding(PaddingOpts{Justification: JustifyLeft, ColumnOverride: map[int]Justification{2: JustifyAuto}, Pad: 0})
+	a.OutputFormat(FormatMarkdown)
+
+	if err := a.Align(); err != nil {
+		t.Fatalf("Align() = %v; want nil", err)
+	}
+
+	// the first line is the header of the table, so the second column is numeric
+	expected := "|a  |  1|\n|:--|--:|\n|b  |  2|\n"
+	if got := out.String(); got != expected {
+		t.Fatalf("Align() = %q; want %q", got, expected)
+	}
+}
+
+func TestJustifyAutoQuoting(t *testing.T) {
+	input := "a,1\nbb,22"
+
+	out := &bytes.Buffer{}
+	a := NewAlign(strings.NewReader(input), out, ",", TextQualifier{})
+	a.UpdatePadding(PaddingOpts{Justification: JustifyAuto, Pad: 1})
+	a.OutputQuoting(QuoteAlways)
+
+	if err := a.Align(); err != nil {
+		t.Fatalf("Align() = %v; want nil", err)
+	}
+
+	// the fields are classified before they are qualified for the output
+	expected := `"a"  ,  "1" ` + "\n" + `"bb" , "22" ` + "\n"
+	if got := out.String(); got != expected {
+		t.Fatalf("Align() = %q; want %q", got, expected)
+	}
+}
@@ -1,44 +1,113 @@
 package main
 
 import (
+	"bytes"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"syscall"
 
 	"github.com/Guitarbum722/align"
 )
 
-const usage = `Usage: align [-h] [-f] [-o] [-q] [-s] [-d] [-a] [-c] [-i] [-p]
+const usage = `Usage: align [-h] [-f] [-o] [-q] [--quote] [-e] [--strip] [-s] [-S] [-T] [-w] [-A] [--repeat] [--fixed] [--nest] [-n] [-d] [-a] [-c] [-i] [-p] [--header] [--halign] [--rule] [--every] [--format] [--style] [--page] [-m] [--csv] [--wide] [--tabs] [--expand] [--ansi] [--spool] [--stream] [--window] [--overflow]
 Options:
   -h | --help  help
   -f           input file.  If not specified, pipe input to stdin
   -o           output file. (default: stdout)
   -q           text qualifier (if applicable)
-  -s           delimiter (default: ',')
-  -d           output delimiter (defaults to the value of sep)
-  -a           <left>, <right>, <center> justification (default: left)
+  --quote      <keep>, <strip>, <always>, <needed> qualifiers in the output (default: keep, or strip for markdown and html)
+  -e           escape character for delimiters within unqualified fields (e.g. '\')
+  --strip      remove the -e escapes from the output
+  -s           delimiter, or 'auto' to detect comma, tab, pipe, semicolon or colon (default: ',')
+  -S           regular expression delimiter, instead of -s (e.g. '\s*=>\s*')
+  -T           space separated delimiter tokens aligned together, instead of -s (e.g. ':= = += |=')
+  -w           split on runs of spaces and tabs, like column -t, instead of -s
+  -A           space separated delimiters for each column boundary in turn, instead of -s (e.g. ': = ,')
+  --repeat     use the last -A delimiter for every column boundary after it
+  --fixed      fixed-width input: field widths (e.g. 10,5,8), start:end cell positions from 0 (e.g. 0:10,12:20,20:) or auto
+  --nest       only split on delimiters outside of (), [], {} and string literals, for aligning code
+  -n           only align on the first n delimiters of each line (default: all)
+  -d           output delimiter (defaults to the value of sep, the matched text of -S or -T, the -A delimiters, or none with -w or --fixed)
+  -a           <left>, <right>, <center>, <auto> justification, where auto right justifies numbers and dates (default: left)
   -c           output specific fields (default: all fields)
-  -i           override justification by column number (e.g. 2:center,5:right)
+  -i           override justification by column number (e.g. 2:center,5:right,6:auto)
   -p           extra padding surrounding delimiter
+  --header     number of header lines (default: 0, or 1 for tables)
+  --halign     <left>, <right>, <center>, <auto> justification of the header (default: as -a and -i)
+  --rule       underline the header with this character (e.g. - or =)
+  --every      repeat the header before every n lines that follow it
+  --format     <text>, <table>, <markdown>, <html> output, where tables have the header, or else the first line, as their header (default: text)
+  --style      <ascii>, <light>, <heavy>, <double>, <rounded>, <none> table borders (default: ascii, implies --format table)
+  --page       write a complete HTML page instead of just the table
+  --csv        parse input as RFC 4180 CSV, where qualified fields may span lines unless --fixed or --nest is given ('"' is the default qualifier)
+  --wide       East-Asian ambiguous-width characters are 2 columns wide
+  --tabs       distance between tab stops within fields (default: 8)
+  --expand     replace tabs within fields with spaces
+  --ansi       ignore ANSI color and hyperlink escape sequences when measuring fields
+  -m           maximum line length (default: 64K, 0 for no limit)
+  --spool      spool piped input larger than this size to a temporary file (e.g. 512K, 64M, 1G)
+  --stream     write each line as it is read, with column widths planned from this many lines
+  --window     plan the column widths again for every --stream lines
+  --overflow   <grow>, <truncate>, <spill> fields wider than the planned width (default: grow)
   `
 
+// exit codes returned by run()
+const (
+	exitUsage = 1 // invalid arguments or unusable input/output files
+	exitRead  = 2 // the input could not be read
+	exitWrite = 3 // the output could not be written
+	exitSpool = 4 // the input could not be spooled to a temporary file
+)
+
 var (
-	hFlag    *bool
-	helpFlag *bool
-	fFlag    *string
-	oFlag    *string
-	qFlag    *string
-	sFlag    *string
-	dFlag    *string
-	aFlag    *string
-	cFlag    *string
-	iFlag    *string
-	pFlag    *int
+	hFlag        *bool
+	helpFlag     *bool
+	fFlag        *string
+	oFlag        *string
+	qFlag        *string
+	quoteFlag    *string
+	eFlag        *string
+	stripFlag    *bool
+	sFlag        *string
+	regFlag      *string
+	tokFlag      *string
+	wFlag        *bool
+	seqFlag      *string
+	repeatFlag   *bool
+	fixedFlag    *string
+	nestFlag     *bool
+	nFlag        *int
+	dFlag        *string
+	aFlag        *string
+	cFlag        *string
+	iFlag        *string
+	pFlag        *int
+	headerFlag   *int
+	hAlignFlag   *string
+	ruleFlag     *string
+	hRepeatFlag  *int
+	formatFlag   *string
+	styleFlag    *string
+	pageFlag     *bool
+	csvFlag      *bool
+	wideFlag     *bool
+	tabsFlag     *int
+	expandFlag   *bool
+	ansiFlag     *bool
+	mFlag        *string
+	spoolFlag    *string
+	streamFlag   *int
+	windowFlag   *bool
+	overflowFlag *string
 )
 
 func main() {
@@ -58,35 +127,197 @@ func init() {
 	fFlag = flag.String("f", "", "")
 	oFlag = flag.String("o", "", "")
 	qFlag = flag.String("q", "", "")
+	quoteFlag = flag.String("quote", "", "")
+	eFlag = flag.String("e", "", "")
+	stripFlag = flag.Bool("strip", false, "")
 	sFlag = flag.String("s", ",", "")
+	regFlag = flag.String("S", "", "")
+	tokFlag = flag.String("T", "", "")
+	wFlag = flag.Bool("w", false, "")
+	seqFlag = flag.String("A", "", "")
+	repeatFlag = flag.Bool("repeat", false, "")
+	fixedFlag = flag.String("fixed", "", "")
+	nestFlag = flag.Bool("nest", false, "")
+	nFlag = flag.Int("n", 0, "")
 	dFlag = flag.String("d", "", "")
 	aFlag = flag.String("a", "left", "")
 	cFlag = flag.String("c", "", "")
 	iFlag = flag.String("i", "", "")
 	pFlag = flag.Int("p", 1, "")
+	headerFlag = flag.Int("header", 0, "")
+	hAlignFlag = flag.String("halign", "", "")
+	ruleFlag = flag.String("rule", "", "")
+	hRepeatFlag = flag.Int("every", 0, "")
+	formatFlag = flag.String("format", "", "")
+	styleFlag = flag.String("style", "", "")
+	pageFlag = flag.Bool("page", false, "")
+	csvFlag = flag.Bool("csv", false, "")
+	wideFlag = flag.Bool("wide", false, "")
+	tabsFlag = flag.Int("tabs", 8, "")
+	expandFlag = flag.Bool("expand", false, "")
+	ansiFlag = flag.Bool("ansi", false, "")
+	mFlag = flag.String("m", "", "")
+	spoolFlag = flag.String("spool", "", "")
+	streamFlag = flag.Int("stream", 0, "")
+	windowFlag = flag.Bool("window", false, "")
+	overflowFlag = flag.String("overflow", "grow", "")
 }
 
 func run() (int, error) {
 	flag.Parse()
-	if *dFlag == "" {
-		*dFlag = *sFlag
-	}
+
+	// the separators matched by a regular expression or tokens are kept unless an output delimiter is given
+	keepSeps := (*regFlag != "" || *tokFlag != "") && *dFlag == ""
+	outSep := *dFlag
 
 	// check for piped input, but use specified input file if supplied
 	fi, _ := os.Stdin.Stat()
 	isPiped := (fi.Mode() & os.ModeCharDevice) == 0
 
 	if *hFlag == true || *helpFlag == true {
-		return 1, errors.New(usage)
+		return exitUsage, errors.New(usage)
 	}
 	if !isPiped {
 		if len(os.Args[1:]) == 0 {
-			return 1, errors.New(usage)
+			return exitUsage, errors.New(usage)
+		}
+	}
+
+	var spoolAt int64
+	if *spoolFlag != "" {
+		n, err := parseSize(*spoolFlag)
+		if err != nil {
+			return exitUsage, errors.New("make sure entry for --spool is a size in bytes with an optional K, M or G suffix (ie 64M)")
+		}
+		spoolAt = n
+	}
+
+	maxLine := -1 // keep the default
+	if *mFlag != "" {
+		n, err := parseSize(*mFlag)
+		if err != nil || n < 0 || n > int64(^uint(0)>>1) {
+			return exitUsage, errors.New("make sure entry for -m is a size in bytes with an optional K, M or G suffix (ie 1M)")
+		}
+		maxLine = int(n)
+	}
+
+	var sepPattern *regexp.Regexp
+	if *regFlag != "" {
+		re, err := regexp.Compile(*regFlag)
+		if err != nil {
+			return exitUsage, fmt.Errorf("make sure entry for -S is a valid regular expression: %v", err)
 		}
+		sepPattern = re
+	}
+
+	// each split mode replaces -s, so only one of them may be given
+	var splitModes int
+	for _, on := range []bool{*regFlag != "", *tokFlag != "", *wFlag, *seqFlag != "", *fixedFlag != ""} {
+		if on {
+			splitModes++
+		}
+	}
+	if splitModes > 1 {
+		return exitUsage, errors.New("make sure only one of -S, -T, -w, -A or --fixed is given")
+	}
+
+	var quoting align.Quoting // the default for the output format
+	switch *quoteFlag {
+	case "":
+	case "keep":
+		quoting = align.QuoteKeep
+	case "strip":
+		quoting = align.QuoteStrip
+	case "always":
+		quoting = align.QuoteAlways
+	case "needed":
+		quoting = align.QuoteNeeded
+	default:
+		return exitUsage, errors.New("make sure entry for --quote is one of keep, strip, always or needed")
+	}
+
+	var style align.Style
+	switch *styleFlag {
+	case "", "ascii":
+		style = align.StyleASCII
+	case "light":
+		style = align.StyleLight
+	case "heavy":
+		style = align.StyleHeavy
+	case "double":
+		style = align.StyleDouble
+	case "rounded":
+		style = align.StyleRounded
+	case "none":
+		style = align.StyleNone
+	default:
+		return exitUsage, errors.New("make sure entry for --style is one of ascii, light, heavy, double, rounded or none")
+	}
+
+	var headerJustify align.Justification
+	switch *hAlignFlag {
+	case "":
+	case "left":
+		headerJustify = align.JustifyLeft
+	case "auto":
+		headerJustify = align.JustifyAuto
+	case "center":
+		headerJustify = align.JustifyCenter
+	case "right":
+		headerJustify = align.JustifyRight
+	default:
+		return exitUsage, errors.New("make sure entry for --halign is one of left, right, center or auto")
+	}
+	if (*hAlignFlag != "" || *ruleFlag != "" || *hRepeatFlag > 0) && *headerFlag <= 0 {
+		*headerFlag = 1
+	}
+
+	var format align.Format
+	switch *formatFlag {
+	case "", "text":
+		format = align.FormatText
+		if *styleFlag != "" && *formatFlag == "" {
+			format = align.FormatTable
+		}
+	case "table":
+		format = align.FormatTable
+	case "markdown":
+		format = align.FormatMarkdown
+	case "html":
+		format = align.FormatHTML
+	default:
+		return exitUsage, errors.New("make sure entry for --format is one of text, table, markdown or html")
+	}
+
+	var fixedWidths []int
+	var fixedCols []align.Column
+	if *fixedFlag != "" && *fixedFlag != "auto" {
+		var err error
+		if strings.Contains(*fixedFlag, ":") {
+			fixedCols, err = parseColumns(*fixedFlag)
+		} else {
+			fixedWidths, err = parseWidths(*fixedFlag)
+		}
+		if err != nil {
+			return exitUsage, errors.New("make sure entry for --fixed is widths (ie 10,5,8), start:end positions (ie 0:10,12:20,20:) or auto")
+		}
+	}
+
+	var overflow align.Overflow
+	switch *overflowFlag {
+	case "grow":
+		overflow = align.OverflowGrow
+	case "truncate":
+		overflow = align.OverflowTruncate
+	case "spill":
+		overflow = align.OverflowSpill
+	default:
+		return exitUsage, errors.New("make sure entry for --overflow is one of grow, truncate or spill")
 	}
 
 	var input io.Reader
 	var output io.Writer
+	var outFile *os.File
 	var qu align.TextQualifier
 	var outColumns []int
 	var justifyOverrides = make(map[int]align.Justification)
@@ -95,13 +326,13 @@ func run() (int, error) {
 		c := strings.Split(*iFlag, ",")
 
 		for _, v := range c {
-			if strings.HasSuffix(v, ":right") || strings.HasSuffix(v, ":center") || strings.HasSuffix(v, ":left") {
+			if strings.HasSuffix(v, ":right") || strings.HasSuffix(v, ":center") || strings.HasSuffix(v, ":left") || strings.HasSuffix(v, ":auto") {
 				overrides := strings.Split(v, ":")
 				v = overrides[0]
 
 				num, err := strconv.Atoi(v)
 				if err != nil {
-					return 1, errors.New("make sure entry for -v are numbers with a justification separated by ':' (ie 1-right,3-center)")
+					return exitUsage, errors.New("make sure entry for -v are numbers with a justification separated by ':' (ie 1-right,3-center)")
 				}
 
 				switch overrides[1] {
@@ -111,12 +342,14 @@ func run() (int, error) {
 					justifyOverrides[num] = align.JustifyCenter
 				case "right":
 					justifyOverrides[num] = align.JustifyRight
+				case "auto":
+					justifyOverrides[num] = align.JustifyAuto
 				}
 			}
 		}
 
 		if len(justifyOverrides) < 1 {
-			return 1, errors.New("make sure entry for -v are numbers with a justification separated by ':' (ie 1:right,3:center)")
+			return exitUsage, errors.New("make sure entry for -v are numbers with a justification separated by ':' (ie 1:right,3:center)")
 		}
 	}
 
@@ -128,7 +361,7 @@ func run() (int, error) {
 		for _, v := range c {
 			num, err := strconv.Atoi(v)
 			if err != nil {
-				return 1, errors.New("make sure entry for -c are numbers (ie 1,2,5,7)")
+				return exitUsage, errors.New("make sure entry for -c are numbers (ie 1,2,5,7)")
 			}
 			if num > 0 {
 				outColumns = append(outColumns, num)
@@ -143,14 +376,23 @@ func run() (int, error) {
 			Qualifier: *qFlag,
 		}
 	}
+	if *csvFlag {
+		if *qFlag == "" {
+			qu.Qualifier = `"`
+		}
+		qu.On = true
+		qu.CSV = true
+		qu.Multiline = true
+	}
 
 	if *oFlag != "" {
 		f, err := os.Create(*oFlag)
 		if err != nil {
-			return 1, err
+			return exitUsage, err
 		}
 		defer f.Close()
 		output = f
+		outFile = f
 	} else {
 		output = os.Stdout
 	}
@@ -159,7 +401,7 @@ func run() (int, error) {
 		if *fFlag != "" {
 			f, err := os.Open(*fFlag)
 			if err != nil {
-				return 1, err
+				return exitUsage, err
 			}
 			defer f.Close()
 			input = f
@@ -170,17 +412,57 @@ func run() (int, error) {
 		if *fFlag != "" {
 			f, err := os.Open(*fFlag)
 			if err != nil {
-				return 1, err
+				return exitUsage, err
 			}
 			defer f.Close()
 			input = f
 		} else {
-			return 1, errors.New("no input provided \n" + usage)
+			return exitUsage, errors.New("no input provided \n" + usage)
 		}
 	}
 
+	if *sFlag == "auto" {
+		sample, rest, err := readSample(input)
+		if err != nil {
+			return exitRead, err
+		}
+		sep, err := align.DetectSeparator(sample, qu)
+		if err != nil {
+			return exitUsage, fmt.Errorf("%v; specify the delimiter with -s", err)
+		}
+		*sFlag = sep
+		input = rest
+	}
+	if *dFlag == "" && !*wFlag && *fixedFlag == "" {
+		*dFlag = *sFlag
+	}
+
 	aligner := align.NewAlign(input, output, *sFlag, qu)
 
+	// input files are read twice rather than held in memory
+	if *fFlag != "" {
+		aligner.TwoPass(true)
+	}
+	aligner.SpoolThreshold(spoolAt)
+	if maxLine >= 0 {
+		aligner.MaxLineLength(maxLine)
+	}
+	aligner.UpdateStream(align.StreamOpts{
+		Lines:    *streamFlag,
+		Window:   *windowFlag,
+		Overflow: overflow,
+	})
+
+	// remove any spooled input if interrupted
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sig)
+	go func() {
+		<-sig
+		aligner.Close()
+		os.Exit(130)
+	}()
+
 	switch *aFlag {
 	case "right":
 		aligner.UpdatePadding(align.PaddingOpts{
@@ -194,6 +476,12 @@ func run() (int, error) {
 			ColumnOverride: justifyOverrides,
 			Pad:            *pFlag,
 		})
+	case "auto":
+		aligner.UpdatePadding(align.PaddingOpts{
+			Justification:  align.JustifyAuto,
+			ColumnOverride: justifyOverrides,
+			Pad:            *pFlag,
+		})
 	default:
 		aligner.UpdatePadding(align.PaddingOpts{
 			Justification:  align.JustifyLeft,
@@ -201,10 +489,190 @@ func run() (int, error) {
 			Pad:            *pFlag,
 		})
 	}
+	aligner.UpdateWidth(align.WidthOpts{
+		EastAsian:  *wideFlag,
+		TabWidth:   *tabsFlag,
+		ExpandTabs: *expandFlag,
+		ANSI:       *ansiFlag,
+	})
+	aligner.UpdateEscape(align.EscapeOpts{
+		Escape: *eFlag,
+		Strip:  *stripFlag,
+	})
+	aligner.OutputQuoting(quoting)
+	aligner.UpdateHeader(align.HeaderOpts{
+		Lines:         *headerFlag,
+		Justification: headerJustify,
+		Rule:          *ruleFlag,
+		Repeat:        *hRepeatFlag,
+	})
+	aligner.OutputFormat(format)
+	aligner.TableStyle(style)
+	title := "align"
+	if *fFlag != "" {
+		title = filepath.Base(*fFlag)
+	}
+	aligner.UpdateHTML(align.HTMLOpts{
+		Page:  *pageFlag,
+		Title: title,
+	})
 	aligner.FilterColumns(outColumns)
 	aligner.OutputSep(*dFlag)
+	switch {
+	case sepPattern != nil:
+		aligner.SplitRegexp(sepPattern)
+	case *tokFlag != "":
+		aligner.SplitTokens(strings.Fields(*tokFlag)...)
+	case *seqFlag != "":
+		var bounds []align.Boundary
+		for _, sep := range strings.Fields(*seqFlag) {
+			bounds = append(bounds, align.Boundary{Sep: sep, SepOut: outSep, Pad: -1})
+		}
+		aligner.SplitSequence(bounds, *repeatFlag)
+	case fixedWidths != nil:
+		aligner.SplitWidths(fixedWidths...)
+	case *fixedFlag != "":
+		aligner.SplitColumns(fixedCols...)
+	case *wFlag:
+		aligner.SplitWhitespace()
+	}
+	if *nestFlag {
+		aligner.UpdateNest(align.DefaultNest)
+	}
+	aligner.KeepSeparators(keepSeps)
+	aligner.MaxSplit(*nFlag)
 
-	aligner.Align()
+	if err := aligner.Align(); err != nil {
+		var rerr *align.ReadError
+		var werr *align.WriteError
+		switch {
+		case errors.As(err, &rerr):
+			return exitRead, err
+		case errors.As(err, &werr):
+			return exitWrite, err
+		}
+		return exitSpool, err
+	}
+
+	// a failed Close may be the only sign that the output file was not completely written
+	if outFile != nil {
+		if err := outFile.Close(); err != nil {
+			return exitWrite, err
+		}
+	}
 
 	return 0, nil
 }
+
+// sample limits for -s auto
+const (
+	sampleLines = 100
+	sampleSize  = 64 << 10
+)
+
+// readSample reads up to the first sampleLines lines of r for align.DetectSeparator.
+// The returned reader reads all of r again from where the sample started.
+func readSample(r io.Reader) (string, io.Reader, error) {
+	var off int64 = -1
+	if s, ok := r.(io.Seeker); ok {
+		if n, err := s.Seek(0, io.SeekCurrent); err == nil {
+			off = n
+		}
+	}
+
+	buf := make([]byte, sampleSize)
+	n, err := io.ReadFull(r, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", nil, err
+	}
+	buf = buf[:n]
+
+	var rest io.Reader
+	if off >= 0 {
+		if _, err := r.(io.Seeker).Seek(off, io.SeekStart); err != nil {
+			return "", nil, err
+		}
+		rest = r
+	} else {
+		rest = io.MultiReader(bytes.NewReader(buf), r)
+	}
+
+	// drop a line cut short by the sample size
+	sample := buf
+	if n == sampleSize {
+		if i := bytes.LastIndexByte(sample, '\n'); i >= 0 {
+			sample = sample[:i+1]
+		}
+	}
+	for i, end := 0, 0; i < sampleLines; i++ {
+		j := bytes.IndexByte(sample[end:], '\n')
+		if j == -1 {
+			break
+		}
+		end += j + 1
+		if i == sampleLines-1 {
+			sample = sample[:end]
+		}
+	}
+
+	return string(sample), rest, nil
+}
+
+// parseWidths parses a list of field widths.
+func parseWidths(s string) ([]int, error) {
+	var widths []int
+	for _, v := range strings.Split(s, ",") {
+		w, err := strconv.Atoi(v)
+		if err != nil || w <= 0 {
+			return nil, errors.New("invalid width")
+		}
+		widths = append(widths, w)
+	}
+	return widths, nil
+}
+
+// parseColumns parses a list of start:end positions, where end may be omitted.
+func parseColumns(s string) ([]align.Column, error) {
+	var cols []align.Column
+	for _, v := range strings.Split(s, ",") {
+		if !strings.Contains(v, ":") {
+			return nil, errors.New("missing start:end position")
+		}
+
+		pos := strings.SplitN(v, ":", 2)
+		c := align.Column{End: -1}
+		var err error
+		if c.Start, err = strconv.Atoi(pos[0]); err != nil || c.Start < 0 {
+			return nil, errors.New("invalid start position")
+		}
+		if pos[1] != "" {
+			if c.End, err = strconv.Atoi(pos[1]); err != nil || c.End <= c.Start {
+				return nil, errors.New("invalid end position")
+			}
+		}
+		cols = append(cols, c)
+	}
+	return cols, nil
+}
+
+// parseSize parses a size in bytes with an optional K, M or G suffix.
+func parseSize(s string) (int64, error) {
+	var mult int64 = 1
+	switch strings.ToUpper(s[len(s)-1:]) {
+	case "K":
+		mult = 1 << 10
+	case "M":
+		mult = 1 << 20
+	case "G":
+		mult = 1 << 30
+	}
+	if mult > 1 {
+		s = s[:len(s)-1]
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return n * mult, nil
+}
@@ -0,0 +1,283 @@
+package align
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// delimiter locates the separators between the fields of a line when
+// they are not all the same literal string.
+type delimiter interface {
+	// index returns the position and length of the first separator in s that
+	// ends the column numbered col, or -1 and 0 if s does not contain one.
+	index(s string, col int) (i, n int)
+}
+
+// pattern is a delimiter that matches a regular expression.
+type pattern struct {
+	re *regexp.Regexp
+}
+
+func (p pattern) index(s string, col int) (int, int) {
+	for off := 0; off < len(s); {
+		loc := p.re.FindStringIndex(s[off:])
+		if loc == nil {
+			return -1, 0
+		}
+		if loc[1] > loc[0] {
+			return off + loc[0], loc[1] - loc[0]
+		}
+
+		// an empty match can't separate two fields; look past it
+		_, size := utf8.DecodeRuneInString(s[off+loc[0]:])
+		off += loc[0] + size
+	}
+	return -1, 0
+}
+
+// tokens is a delimiter that matches any of a set of literal strings, along with
+// the blanks around it.  The strings are sorted from longest to shortest.
+type tokens []string
+
+func (t tokens) index(s string, col int) (int, int) {
+	start, n := -1, 0
+	for _, tok := range t {
+		if i := strings.Index(s, tok); i != -1 && (start == -1 || i < start) {
+			start, n = i, len(tok)
+		}
+	}
+	if start == -1 {
+		return -1, 0
+	}
+
+	return withBlanks(s, start, start+n)
+}
+
+// withBlanks extends the separator in s from start to end over the blanks around it.
+func withBlanks(s string, start, end int) (int, int) {
+	for start > 0 && isBlank(s[start-1]) {
+		start--
+	}
+	for end < len(s) && isBlank(s[end]) {
+		end++
+	}
+	return start, end - start
+}
+
+func isBlank(c byte) bool {
+	return c == ' ' || c == '\t'
+}
+
+// blanks is a delimiter that matches any run of spaces and tabs.
+type blanks struct{}
+
+func (blanks) index(s string, col int) (int, int) {
+	start := strings.IndexAny(s, " \t")
+	if start == -1 {
+		return -1, 0
+	}
+
+	end := start + 1
+	for end < len(s) && isBlank(s[end]) {
+		end++
+	}
+	return start, end - start
+}
+
+// SplitWhitespace splits each line into fields at every run of spaces and tabs instead of the
+// literal separator given to NewAlign, like column -t.  Leading and trailing blanks are ignored,
+// so text that is already aligned can be aligned again.
+func (a *Align) SplitWhitespace() {
+	a.delim = blanks{}
+}
+
+// Boundary configures the separator at one column boundary.  See SplitSequence.
+type Boundary struct {
+	Sep    string // separator that ends the column
+	SepOut string // output separator (default: Sep)
+	Pad    int    // padding surrounding the output separator; -1 uses PaddingOpts.Pad
+}
+
+// sequence is a delimiter that matches a different separator at each column boundary,
+// along with the blanks around it.
+type sequence struct {
+	bounds []Boundary
+	repeat bool // the last boundary applies to every column after it
+}
+
+// boundary returns the Boundary that ends the column numbered col.
+func (q sequence) boundary(col int) (Boundary, bool) {
+	if col < len(q.bounds) {
+		return q.bounds[col], true
+	}
+	if q.repeat && len(q.bounds) > 0 {
+		return q.bounds[len(q.bounds)-1], true
+	}
+	return Boundary{}, false
+}
+
+func (q sequence) index(s string, col int) (int, int) {
+	b, ok := q.boundary(col)
+	if !ok || b.Sep == "" {
+		return -1, 0
+	}
+
+	start := strings.Index(s, b.Sep)
+	if start == -1 {
+		return -1, 0
+	}
+	return withBlanks(s, start, start+len(b.Sep))
+}
+
+// SplitSequence sets a different separator for each column boundary instead of the literal
+// separator given to NewAlign, like the AlignTab plugin.  The first column ends at the first
+// bounds[0].Sep, the second column ends at the following bounds[1].Sep, and so on.  If repeat is
+// true, the last Boundary is used for every column after it.  Otherwise the rest of the line after
+// the last Boundary is the last field.  The blanks around each separator are part of the separator.
+// Each Boundary's SepOut and Pad are used in the output instead of the output separator and PaddingOpts.Pad.
+func (a *Align) SplitSequence(bounds []Boundary, repeat bool) {
+	a.delim = sequence{bounds: bounds, repeat: repeat}
+}
+
+// boundaryPad returns the padding surrounding the output separator that ends the column numbered col.
+func (a *Align) boundaryPad(col int) string {
+	pad := a.padOpts.Pad
+	if q, ok := a.delim.(sequence); ok {
+		if b, ok := q.boundary(col); ok && b.Pad >= 0 {
+			pad = b.Pad
+		}
+	}
+	return strings.Repeat(string(padchar), pad)
+}
+
+// SplitTokens sets a group of separator tokens, such as :=, = and +=, that all split each
+// line into fields at the same alignment point instead of the literal separator given to NewAlign.
+// Where tokens overlap, the one that starts first is used, and then the longest.  The blanks
+// around each token are part of the separator.
+// With KeepSeparators set, each token is written right-aligned within the widest token
+// at its column boundary, so that x := 1 and foo = 2 line up on the =.
+func (a *Align) SplitTokens(toks ...string) {
+	t := make(tokens, 0, len(toks))
+	for _, tok := range toks {
+		if tok != "" {
+			t = append(t, tok)
+		}
+	}
+	sort.SliceStable(t, func(i, j int) bool {
+		return len(t[i]) > len(t[j])
+	})
+	a.delim = t
+}
+
+// SplitRegexp sets the regular expression re to split each line into fields instead of the
+// literal separator given to NewAlign.  Matches of re within a qualified field are ignored,
+// as are matches of the empty string.
+// The separators are written as the output separator unless KeepSeparators is set.
+func (a *Align) SplitRegexp(re *regexp.Regexp) {
+	a.delim = pattern{re}
+}
+
+// KeepSeparators sets whether the separator text found in the input is written instead of the
+// output separator.  Any whitespace around each separator is replaced by the padding, and
+// separators of different widths at the same column boundary are right-aligned.
+func (a *Align) KeepSeparators(on bool) {
+	a.keepSeps = on
+}
+
+// MaxSplit sets the maximum number of separators n in each line that are column boundaries.
+// The rest of the line after the nth separator is the last field, and is written unchanged.
+// If n is 0 or less (the default), every separator is a column boundary.
+func (a *Align) MaxSplit(n int) {
+	a.maxSplit = n
+}
+
+// split splits s into its fields and the separators that follow each field but the last.
+// If every separator is the Align's literal sep and nesting is off, seps is nil.
+func (a *Align) split(s string) (words, seps []string) {
+	if f, ok := a.delim.(*fixedWidth); ok {
+		return f.split(s, a.widthOpts), nil
+	}
+	if a.nestOpts.on() {
+		return a.splitNested(s)
+	}
+	if a.delim == nil {
+		return a.splitWithQual(s, a.sep, a.txtq.Qualifier), nil
+	}
+	if _, ok := a.delim.(blanks); ok {
+		s = strings.Trim(s, " \t")
+	}
+
+	for start := 0; ; {
+		if a.maxSplit > 0 && len(words) == a.maxSplit {
+			return append(words, s[start:]), seps
+		}
+
+		var q int
+		if a.txtq.On {
+			q = qualifiedLen(s[start:], a.txtq.Qualifier, a.txtq.CSV)
+		}
+
+		i, n := a.delim.index(s[start+q:], len(words))
+		if i == -1 {
+			return append(words, s[start:]), seps
+		}
+
+		end := start + q + i
+		words = append(words, s[start:end])
+		seps = append(seps, s[end:end+n])
+		start = end + n
+	}
+}
+
+// qualifiedLen returns the length of the qualified section at the start of s including
+// its closing qual, or 0 if s does not begin with qual.  If csv is true, a doubled
+// qual does not close the section.  An unterminated section runs to the end of s.
+func qualifiedLen(s, qual string, csv bool) int {
+	if len(qual) == 0 || !strings.HasPrefix(s, qual) {
+		return 0
+	}
+
+	endIdx := len(qual)
+	for {
+		closeIdx := strings.Index(s[endIdx:], qual)
+		if closeIdx == -1 {
+			return len(s)
+		}
+		endIdx += closeIdx + len(qual)
+
+		if !csv || !strings.HasPrefix(s[endIdx:], qual) {
+			return endIdx
+		}
+		endIdx += len(qual) // escaped qualifier
+	}
+}
+
+// outputSep returns the separator to write after the field numbered columnNum,
+// given the separators found by split.
+func (a *Align) outputSep(columnNum int, seps []string) string {
+	if q, ok := a.delim.(sequence); ok && !a.keepSeps {
+		if b, ok := q.boundary(columnNum); ok {
+			if b.SepOut != "" {
+				return b.SepOut
+			}
+			return b.Sep
+		}
+	}
+	if !a.keepSeps {
+		return a.sepOut
+	}
+	if seps == nil {
+		return strings.TrimSpace(a.sep)
+	}
+	if columnNum >= len(seps) {
+		return a.sepOut
+	}
+
+	sep := strings.TrimSpace(seps[columnNum])
+	if pad := a.sepCounts[columnNum] - a.widthOpts.width(sep); pad > 0 {
+		return strings.Repeat(string(padchar), pad) + sep
+	}
+	return sep
+}
@@ -0,0 +1,324 @@
+package align
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var patternIndexCases = []struct {
+	re     string
+	input  string
+	expIdx int
+	expLen int
+}{
+	{`\s+`, "one  two", 3, 2},
+	{`\s*=>\s*`, "key => value", 3, 4},
+	{`\s*[:=]\s*`, "key:value", 3, 1},
+	{`\s*`, "ab cd", 2, 1}, // empty matches are skipped
+	{`\s*`, "abcd", -1, 0},
+	{`,`, "", -1, 0},
+}
+
+func TestPatternIndex(t *testing.T) {
+	for _, tt := range patternIndexCases {
+		i, n := pattern{regexp.MustCompile(tt.re)}.index(tt.input, 0)
+		if i != tt.expIdx || n != tt.expLen {
+			t.Fatalf("index(%q) with %v = %v, %v; want %v, %v", tt.input, tt.re, i, n, tt.expIdx, tt.expLen)
+		}
+	}
+}
+
+var splitRegexpCases = []struct {
+	re       string
+	qual     TextQualifier
+	input    string
+	expWords []string
+	expSeps  []string
+}{
+	{
+		`\s*=>\s*`,
+		TextQualifier{},
+		"a => 1",
+		[]string{"a", "1"},
+		[]string{" => "},
+	},
+	{
+		`\s*[:=]\s*`,
+		TextQualifier{},
+		"host = localhost:8080",
+		[]string{"host", "localhost", "8080"},
+		[]string{" = ", ":"},
+	},
+	{
+		`\s*[:=]\s*`,
+		TextQualifier{On: true, Qualifier: `"`},
+		`host = "localhost:8080"`,
+		[]string{"host", `"localhost:8080"`},
+		[]string{" = "},
+	},
+	{
+		`\s*,\s*`,
+		TextQualifier{On: true, Qualifier: `"`, CSV: true},
+		`"a "", b" , c`,
+		[]string{`"a "", b"`, "c"},
+		[]string{" , "},
+	},
+	{
+		`\s+`,
+		TextQualifier{},
+		"",
+		[]string{""},
+		nil,
+	},
+}
+
+func TestSplitRegexp(t *testing.T) {
+	for _, tt := range splitRegexpCases {
+		a := NewAlign(strings.NewReader(""), &bytes.Buffer{}, comma, tt.qual)
+		a.SplitRegexp(regexp.MustCompile(tt.re))
+
+		words, seps := a.split(tt.input)
+		if strings.Join(words, "|") != strings.Join(tt.expWords, "|") || len(words) != len(tt.expWords) {
+			t.Fatalf("split(%q) words = %q; want %q", tt.input, words, tt.expWords)
+		}
+		if strings.Join(seps, "|") != strings.Join(tt.expSeps, "|") || len(seps) != len(tt.expSeps) {
+			t.Fatalf("split(%q) seps = %q; want %q", tt.input, seps, tt.expSeps)
+		}
+	}
+}
+
+func TestExportRegexp(t *testing.T) {
+	input := "a => 1\nlonger=2\nc   =>   3"
+
+	var cases = []struct {
+		keep     bool
+		expected string
+	}{
+		{
+			false,
+			"a      => 1 \nlonger => 2 \nc      => 3 \n",
+		},
+		{
+			true,
+			"a      => 1 \nlonger  = 2 \nc      => 3 \n",
+		},
+	}
+
+	for _, tt := range cases {
+		out := &bytes.Buffer{}
+		a := NewAlign(strings.NewReader(input), out, comma, TextQualifier{})
+		a.SplitRegexp(regexp.MustCompile(`\s*=>?\s*`))
+		a.OutputSep("=>")
+		a.KeepSeparators(tt.keep)
+
+		if err := a.Align(); err != nil {
+			t.Fatalf("Align() = %v; want nil", err)
+		}
+		if got := out.String(); got != tt.expected {
+			t.Fatalf("Align() with KeepSeparators(%v) = %q; want %q", tt.keep, got, tt.expected)
+		}
+	}
+}
+
+var tokensIndexCases = []struct {
+	input  string
+	expIdx int
+	expLen int
+}{
+	{"x := 1", 1, 4},
+	{"foo = 2", 3, 3},
+	{"bar+=3", 3, 2},
+	{"flags |= x == y", 5, 4},
+	{"none", -1, 0},
+}
+
+func TestTokensIndex(t *testing.T) {
+	a := NewAlign(strings.NewReader(""), &bytes.Buffer{}, comma, TextQualifier{})
+	a.SplitTokens("=", ":=", "+=", "|=", "")
+
+	for _, tt := range tokensIndexCases {
+		i, n := a.delim.index(tt.input, 0)
+		if i != tt.expIdx || n != tt.expLen {
+			t.Fatalf("index(%q) = %v, %v; want %v, %v", tt.input, i, n, tt.expIdx, tt.expLen)
+		}
+	}
+}
+
+func TestExportTokens(t *testing.T) {
+	input := "x := 1\nfoo = 2\nbar += 3\nflags |= \"a=b\""
+
+	var cases = []struct {
+		keep     bool
+		expected string
+	}{
+		{
+			true,
+			"x     := 1     \nfoo    = 2     \nbar   += 3     \nflags |= \"a=b\" \n",
+		},
+		{
+			false,
+			"x     = 1     \nfoo   = 2     \nbar   = 3     \nflags = \"a=b\" \n",
+		},
+	}
+
+	for _, tt := range cases {
+		out := &bytes.Buffer{}
+		a := NewAlign(strings.NewReader(input), out, "=", TextQualifier{On: true, Qualifier: `"`})
+		a.SplitTokens(":=", "=", "+=", "|=")
+		a.KeepSeparators(tt.keep)
+
+		if err := a.Align(); err != nil {
+			t.Fatalf("Align() = %v; want nil", err)
+		}
+		if got := out.String(); got != tt.expected {
+			t.Fatalf("Align() with KeepSeparators(%v) = %q; want %q", tt.keep, got, tt.expected)
+		}
+	}
+}
+
+var splitWhitespaceCases = []struct {
+	input    string
+	expected []string
+}{
+	{"a b", []string{"a", "b"}},
+	{"  PID TTY      TIME CMD", []string{"PID", "TTY", "TIME", "CMD"}},
+	{"a\t\t b  \t", []string{"a", "b"}},
+	{"\"hello world\"  x", []string{"\"hello world\"", "x"}},
+	{"   ", []string{""}},
+}
+
+func TestSplitWhitespace(t *testing.T) {
+	a := NewAlign(strings.NewReader(""), &bytes.Buffer{}, " ", TextQualifier{On: true, Qualifier: `"`})
+	a.SplitWhitespace()
+
+	for _, tt := range splitWhitespaceCases {
+		words, _ := a.split(tt.input)
+		if strings.Join(words, "|") != strings.Join(tt.expected, "|") || len(words) != len(tt.expected) {
+			t.Fatalf("split(%q) = %q; want %q", tt.input, words, tt.expected)
+		}
+	}
+}
+
+func TestExportWhitespace(t *testing.T) {
+	input := `  PID TTY          TIME CMD
+ 4242 pts/0    00:00:00 bash
+12345 pts/0    00:00:01 kubectl`
+
+	out := &bytes.Buffer{}
+	a := NewAlign(strings.NewReader(input), out, " ", TextQualifier{})
+	a.SplitWhitespace()
+	a.OutputSep("")
+	a.UpdatePadding(PaddingOpts{Justification: JustifyLeft, ColumnOverride: map[int]Justification{1: JustifyRight}, Pad: 1})
+
+	if err := a.Align(); err != nil {
+		t.Fatalf("Align() = %v; want nil", err)
+	}
+
+	expected := `  PID  TTY    TIME      CMD     
+ 4242  pts/0  00:00:00  bash    
+12345  pts/0  00:00:01  kubectl 
+`
+	if got := out.String(); got != expected {
+		t.Fatalf("Align() = \n%v; want\n%v", got, expected)
+	}
+}
+
+var maxSplitCases = []struct {
+	input    string
+	qual     TextQualifier
+	tokens   []string
+	maxSplit int
+	expected []string
+}{
+	{`url=a=b&c=d`, TextQualifier{}, nil, 1, []string{"url", "a=b&c=d"}},
+	{`a=b=c=d`, TextQualifier{}, nil, 2, []string{"a", "b", "c=d"}},
+	{`a=b=c=d`, TextQualifier{}, nil, 0, []string{"a", "b", "c", "d"}},
+	{`"k=1"=v=w`, TextQualifier{On: true, Qualifier: `"`}, nil, 1, []string{`"k=1"`, "v=w"}},
+	{`x := a == b`, TextQualifier{}, []string{":=", "=="}, 1, []string{"x", "a == b"}},
+	{`a=b`, TextQualifier{}, nil, 5, []string{"a", "b"}},
+}
+
+func TestMaxSplit(t *testing.T) {
+	for _, tt := range maxSplitCases {
+		a := NewAlign(strings.NewReader(""), &bytes.Buffer{}, "=", tt.qual)
+		if tt.tokens != nil {
+			a.SplitTokens(tt.tokens...)
+		}
+		a.MaxSplit(tt.maxSplit)
+
+		words, _ := a.split(tt.input)
+		if strings.Join(words, "|") != strings.Join(tt.expected, "|") || len(words) != len(tt.expected) {
+			t.Fatalf("split(%q) with MaxSplit(%v) = %q; want %q", tt.input, tt.maxSplit, words, tt.expected)
+		}
+	}
+}
+
+func TestScanRecordsMaxSplit(t *testing.T) {
+	input := "key=\"a\nb\"\nx=y=\"z\nnext=1"
+
+	out := &bytes.Buffer{}
+	a := NewAlign(strings.NewReader(input), out, "=", TextQualifier{On: true, Qualifier: `"`, Multiline: true})
+	a.MaxSplit(1)
+
+	if err := a.Align(); err != nil {
+		t.Fatalf("Align() = %v; want nil", err)
+	}
+
+	expected := "key  = \"a   \n     = b\"   \nx    = y=\"z \nnext = 1    \n"
+	if got := out.String(); got != expected {
+		t.Fatalf("Align() = %q; want %q", got, expected)
+	}
+}
+
+var splitSequenceCases = []struct {
+	input    string
+	repeat   bool
+	expected []string
+}{
+	{"a : b = c , d , e", false, []string{"a", "b", "c", "d , e"}},
+	{"a : b = c , d , e", true, []string{"a", "b", "c", "d", "e"}},
+	{"a = b : c", false, []string{"a = b", "c"}},
+	{"a : b", true, []string{"a", "b"}},
+	{"abc", true, []string{"abc"}},
+}
+
+func TestSplitSequence(t *testing.T) {
+	for _, tt := range splitSequenceCases {
+		a := NewAlign(strings.NewReader(""), &bytes.Buffer{}, ",", TextQualifier{})
+		a.SplitSequence([]Boundary{{Sep: ":"}, {Sep: "="}, {Sep: ","}}, tt.repeat)
+
+		words, _ := a.split(tt.input)
+		if strings.Join(words, "|") != strings.Join(tt.expected, "|") || len(words) != len(tt.expected) {
+			t.Fatalf("split(%q) = %q; want %q", tt.input, words, tt.expected)
+		}
+	}
+}
+
+func TestExportSequence(t *testing.T) {
+	input := `name: x = 1, 2, 3
+longer_name: yy = 10, 20
+z: w = 100`
+
+	out := &bytes.Buffer{}
+	a := NewAlign(strings.NewReader(input), out, ",", TextQualifier{})
+	a.UpdatePadding(PaddingOpts{Justification: JustifyLeft, Pad: 1})
+	a.SplitSequence([]Boundary{
+		{Sep: ":", Pad: 0},
+		{Sep: "=", SepOut: "=>", Pad: -1},
+		{Sep: ",", Pad: 2},
+	}, true)
+
+	if err := a.Align(); err != nil {
+		t.Fatalf("Align() = %v; want nil", err)
+	}
+
+	expected := `name       :x  => 1    ,  2   ,  3  
+longer_name:yy => 10   ,  20  
+z          :w  => 100  
+`
+	if got := out.String(); got != expected {
+		t.Fatalf("Align() = \n%v; want\n%v", got, expected)
+	}
+}
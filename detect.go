@@ -0,0 +1,97 @@
+package align
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// detectCandidates are the separators considered by DetectSeparator, in order of preference.
+var detectCandidates = []string{",", "\t", "|", ";", ":"}
+
+// DetectError is returned by DetectSeparator when it cannot choose a single separator.
+type DetectError struct {
+	Candidates []string // separators that fit the sample equally well, if any
+}
+
+func (e *DetectError) Error() string {
+	if len(e.Candidates) == 0 {
+		return "align: no separator found in sample"
+	}
+	return fmt.Sprintf("align: ambiguous separator, could be any of %q", e.Candidates)
+}
+
+// detectScore describes how well a candidate separator fits a sample.
+type detectScore struct {
+	sep        string
+	fields     int // most common number of fields
+	consistent int // number of records with that many fields
+}
+
+// DetectSeparator chooses the separator of the records in sample, such as the first lines of a file.
+// Each of comma, tab, pipe, semicolon and colon is tried, splitting the records according to txtq,
+// and the one that most consistently splits the records into the same number of fields (more than one)
+// is returned.  If another candidate is just as consistent with the same number of fields,
+// or no candidate splits any record, a *DetectError is returned instead.
+func DetectSeparator(sample string, txtq TextQualifier) (string, error) {
+	var best []detectScore
+	for _, sep := range detectCandidates {
+		s, ok := scoreSeparator(sample, sep, txtq)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case len(best) == 0 || s.consistent > best[0].consistent ||
+			s.consistent == best[0].consistent && s.fields > best[0].fields:
+			best = []detectScore{s}
+		case s.consistent == best[0].consistent && s.fields == best[0].fields:
+			best = append(best, s)
+		}
+	}
+
+	if len(best) != 1 {
+		err := &DetectError{}
+		for _, s := range best {
+			err.Candidates = append(err.Candidates, s.sep)
+		}
+		return "", err
+	}
+	return best[0].sep, nil
+}
+
+// scoreSeparator splits the records of sample by sep.  It returns false if no record
+// has more than one field, or if the sample cannot be read with sep.
+func scoreSeparator(sample, sep string, txtq TextQualifier) (detectScore, bool) {
+	a := NewAlign(strings.NewReader(sample), io.Discard, sep, txtq)
+	a.MaxLineLength(0)
+
+	counts := make(map[int]int)
+	for a.scanner.Scan() {
+		line := a.scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		// a closing qualifier is assumed to be followed by the separator,
+		// so a split is only counted if the separator is really there
+		words, _ := a.split(line)
+		if strings.Join(words, sep) != line {
+			words = words[:1]
+		}
+		counts[len(words)]++
+	}
+	if a.scanner.Err() != nil {
+		return detectScore{}, false
+	}
+
+	s := detectScore{sep: sep}
+	for fields, n := range counts {
+		if fields < 2 {
+			continue
+		}
+		if n > s.consistent || n == s.consistent && fields > s.fields {
+			s.fields, s.consistent = fields, n
+		}
+	}
+	return s, s.consistent > 0
+}
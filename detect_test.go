@@ -0,0 +1,50 @@
+package align
+
+import (
+	"errors"
+	"testing"
+)
+
+var detectSeparatorCases = []struct {
+	sample     string
+	txtq       TextQualifier
+	expected   string
+	candidates []string
+}{
+	{"a,b,c\nd,e,f\n", TextQualifier{}, ",", nil},
+	{"a\tb\tc\nd\te\tf\n", TextQualifier{}, "\t", nil},
+	{"a|b|c\nd|e|f\ng|h\n", TextQualifier{}, "|", nil},
+	{"a;b,c;d\ne;f;g\n", TextQualifier{}, ";", nil},
+	{"time,msg\n12:30,up\n12:31,down\n", TextQualifier{}, ",", nil},
+	{"\"a;b\",c\n\"d;e\",f\n", TextQualifier{On: true, Qualifier: `"`}, ",", nil},
+	{"\"a,b\";c\n\"d,e\";f\n", TextQualifier{On: true, Qualifier: `"`, CSV: true}, ";", nil},
+	{"\"a\nb,c\";d\ne;f\n", TextQualifier{On: true, Qualifier: `"`, CSV: true, Multiline: true}, ";", nil},
+	{"a,b;c\nd,e;f\n", TextQualifier{}, "", []string{",", ";"}},
+	{"abc\n\ndef\n", TextQualifier{}, "", nil},
+	{"", TextQualifier{}, "", nil},
+}
+
+func TestDetectSeparator(t *testing.T) {
+	for _, tt := range detectSeparatorCases {
+		got, err := DetectSeparator(tt.sample, tt.txtq)
+		if tt.expected != "" {
+			if err != nil || got != tt.expected {
+				t.Fatalf("DetectSeparator(%q) = %q, %v; want %q, nil", tt.sample, got, err, tt.expected)
+			}
+			continue
+		}
+
+		var derr *DetectError
+		if !errors.As(err, &derr) {
+			t.Fatalf("DetectSeparator(%q) = %q, %v; want *DetectError", tt.sample, got, err)
+		}
+		if len(derr.Candidates) != len(tt.candidates) {
+			t.Fatalf("DetectSeparator(%q) candidates = %q; want %q", tt.sample, derr.Candidates, tt.candidates)
+		}
+		for i := range tt.candidates {
+			if derr.Candidates[i] != tt.candidates[i] {
+				t.Fatalf("DetectSeparator(%q) candidates = %q; want %q", tt.sample, derr.Candidates, tt.candidates)
+			}
+		}
+	}
+}
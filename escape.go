@@ -0,0 +1,68 @@
+package align
+
+import "strings"
+
+// EscapeOpts provides configurability for separators that are escaped within unqualified fields,
+// such as a\,b in the output of MySQL's SELECT INTO OUTFILE.
+type EscapeOpts struct {
+	Escape string // escapes a following separator or Escape; an empty string disables escaping
+	Strip  bool   // remove the escapes from the output
+}
+
+// UpdateEscape uses EscapeOpts e to update the Align's escape options.
+// An escaped separator or escaped Escape does not split a field.  An Escape followed
+// by anything else is kept as text, even with Strip set.  Escapes apply to the separator
+// given to NewAlign, and not within qualified fields.
+func (a *Align) UpdateEscape(e EscapeOpts) {
+	a.escOpts = e
+}
+
+// escapedFieldLen works in the same way as fieldLen, but an escaped sep or esc does not end the field.
+func escapedFieldLen(s, sep, esc string) int {
+	for i := 0; i < len(s); {
+		if n := escapedLen(s[i:], sep, esc); n > 0 {
+			i += n
+			continue
+		}
+		if strings.HasPrefix(s[i:], sep) {
+			return i
+		}
+		i++
+	}
+	return len(s)
+}
+
+// escapedLen returns the length of the escaped sep or esc at the start of s, including its escape,
+// or 0 if s does not begin with one.
+func escapedLen(s, sep, esc string) int {
+	if !strings.HasPrefix(s, esc) {
+		return 0
+	}
+
+	switch rest := s[len(esc):]; {
+	case len(sep) > 0 && strings.HasPrefix(rest, sep):
+		return len(esc) + len(sep)
+	case strings.HasPrefix(rest, esc):
+		return 2 * len(esc)
+	}
+	return 0
+}
+
+// unescape removes the escape from each escaped sep or esc in s.
+func unescape(s, sep, esc string) string {
+	if !strings.Contains(s, esc) {
+		return s
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(s); {
+		if n := escapedLen(s[i:], sep, esc); n > 0 {
+			sb.WriteString(s[i+len(esc) : i+n])
+			i += n
+			continue
+		}
+		sb.WriteByte(s[i])
+		i++
+	}
+	return sb.String()
+}
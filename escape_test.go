@@ -0,0 +1,80 @@
+package align
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+var escapedFieldLenCases = []struct {
+	input    string
+	sep      string
+	esc      string
+	expected int
+}{
+	{`a,b`, ",", `\`, 1},
+	{`a\,b,c`, ",", `\`, 4},
+	{`a\\,b`, ",", `\`, 3},
+	{`a\\\,b,c`, ",", `\`, 6},
+	{`a\nb,c`, ",", `\`, 4},
+	{`foo\|bar|baz`, "|", `\`, 8},
+	{`abc\`, ",", `\`, 4},
+	{`a::,b`, ",", "::", 5},
+}
+
+func TestEscapedFieldLen(t *testing.T) {
+	for _, tt := range escapedFieldLenCases {
+		if got := escapedFieldLen(tt.input, tt.sep, tt.esc); got != tt.expected {
+			t.Fatalf("escapedFieldLen(%q, %q, %q) = %v; want %v", tt.input, tt.sep, tt.esc, got, tt.expected)
+		}
+	}
+}
+
+var splitEscapedCases = []struct {
+	input    string
+	txtq     TextQualifier
+	strip    bool
+	expected []string
+}{
+	{`a\,b,c`, TextQualifier{}, false, []string{`a\,b`, "c"}},
+	{`a\,b,c`, TextQualifier{}, true, []string{"a,b", "c"}},
+	{`a\\,b\n,c`, TextQualifier{}, true, []string{`a\`, `b\n`, "c"}},
+	{`"x\",y",a\,b`, TextQualifier{On: true, Qualifier: `"`}, true, []string{`"x\"`, `y"`, "a,b"}},
+	{`"x\,y",a\,b`, TextQualifier{On: true, Qualifier: `"`, CSV: true}, true, []string{`"x\,y"`, "a,b"}},
+	{`a,b`, TextQualifier{}, true, []string{"a", "b"}},
+}
+
+func TestSplitEscaped(t *testing.T) {
+	for _, tt := range splitEscapedCases {
+		a := NewAlign(strings.NewReader(""), &bytes.Buffer{}, ",", tt.txtq)
+		a.UpdateEscape(EscapeOpts{Escape: `\`, Strip: tt.strip})
+
+		words, _ := a.split(tt.input)
+		if strings.Join(words, "|") != strings.Join(tt.expected, "|") || len(words) != len(tt.expected) {
+			t.Fatalf("split(%q) with Strip %v = %q; want %q", tt.input, tt.strip, words, tt.expected)
+		}
+	}
+}
+
+func TestExportEscaped(t *testing.T) {
+	input := `foo\|bar|baz
+x|y\\|z`
+
+	for _, strip := range []bool{false, true} {
+		out := &bytes.Buffer{}
+		a := NewAlign(strings.NewReader(input), out, "|", TextQualifier{})
+		a.UpdateEscape(EscapeOpts{Escape: `\`, Strip: strip})
+
+		if err := a.Align(); err != nil {
+			t.Fatalf("Align() = %v; want nil", err)
+		}
+
+		expected := "foo\\|bar | baz \nx        | y\\\\ | z \n"
+		if strip {
+			expected = "foo|bar | baz \nx       | y\\  | z \n"
+		}
+		if got := out.String(); got != expected {
+			t.Fatalf("Align() with Strip %v = %q; want %q", strip, got, expected)
+		}
+	}
+}
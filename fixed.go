@@ -0,0 +1,117 @@
+package align
+
+import "strings"
+
+// Column is the position of a field in fixed-width input, in display cells counted from 0.
+type Column struct {
+	Start int
+	End   int // end of the field, exclusive; -1 for the end of the line
+}
+
+// fixedWidth is a delimiter for fixed-width input, where each field is found by its position.
+type fixedWidth struct {
+	cols   []Column
+	infer  bool   // the columns are inferred from the blank cells of the input
+	filled []bool // cells that are not blank on some line, while inferring the columns
+}
+
+// index never finds a separator, as the fields of fixed-width input are split by position.
+func (f *fixedWidth) index(s string, col int) (int, int) {
+	return -1, 0
+}
+
+// SplitColumns splits fixed-width input into the given columns instead of splitting it by a separator.
+// The columns must be in order and must not overlap.  Text that is not within a column is ignored,
+// and the spaces and tabs around each field are removed.  The fields of each line are then aligned
+// and separated by the output separator, so use OutputSep to convert the input to a delimited format.
+// If no columns are given, they are inferred from the input: each column ends where every line
+// has a blank cell, and the last column runs to the end of the line.  When streaming, the columns
+// are inferred from the lines used to plan the first column widths.
+func (a *Align) SplitColumns(cols ...Column) {
+	a.delim = &fixedWidth{cols: cols, infer: len(cols) == 0}
+}
+
+// SplitWidths splits fixed-width input into fields of the given widths, in display cells.
+// Any text after the last width is one more field.  See SplitColumns.
+func (a *Align) SplitWidths(widths ...int) {
+	cols := make([]Column, 0, len(widths)+1)
+	var start int
+	for _, w := range widths {
+		cols = append(cols, Column{Start: start, End: start + w})
+		start += w
+	}
+	a.SplitColumns(append(cols, Column{Start: start, End: -1})...)
+}
+
+// split returns the fields of s within the columns.  A line that ends before a column has no field for it.
+func (f *fixedWidth) split(s string, w WidthOpts) []string {
+	words := make([]string, 0, len(f.cols))
+
+	var i, cell int
+	for _, c := range f.cols {
+		for i < len(s) && cell < c.Start {
+			size, cells := w.next(s[i:], cell)
+			i, cell = i+size, cell+cells
+		}
+		if i >= len(s) {
+			break
+		}
+
+		start := i
+		for i < len(s) && (c.End < 0 || cell < c.End) {
+			size, cells := w.next(s[i:], cell)
+			i, cell = i+size, cell+cells
+		}
+		words = append(words, strings.Trim(s[start:i], " \t"))
+	}
+	return words
+}
+
+// observe marks the cells of s that are not blank.
+func (f *fixedWidth) observe(s string, w WidthOpts) {
+	var cell int
+	for i := 0; i < len(s); {
+		size, cells := w.next(s[i:], cell)
+		if !isBlank(s[i]) {
+			for len(f.filled) < cell+cells {
+				f.filled = append(f.filled, false)
+			}
+			for j := cell; j < cell+cells; j++ {
+				f.filled[j] = true
+			}
+		}
+		i, cell = i+size, cell+cells
+	}
+}
+
+// inferColumns sets the columns to each run of cells that are not blank on every line.
+func (f *fixedWidth) inferColumns() {
+	f.cols = f.cols[:0]
+	for i := 0; i < len(f.filled); i++ {
+		if !f.filled[i] {
+			continue
+		}
+		start := i
+		for i < len(f.filled) && f.filled[i] {
+			i++
+		}
+		f.cols = append(f.cols, Column{Start: start, End: i})
+	}
+
+	if len(f.cols) == 0 {
+		f.cols = append(f.cols, Column{Start: 0, End: -1})
+	}
+	f.cols[len(f.cols)-1].End = -1
+	f.infer, f.filled = false, nil
+}
+
+// inferColumns infers the columns of fixed-width input once all of its lines have been observed,
+// and reports whether the lines must be measured again.
+func (a *Align) inferColumns() bool {
+	f, ok := a.delim.(*fixedWidth)
+	if !ok || !f.infer {
+		return false
+	}
+	f.inferColumns()
+	return true
+}
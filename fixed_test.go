@@ -0,0 +1,108 @@
+package align
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+var splitColumnsCases = []struct {
+	input    string
+	cols     []Column
+	expected []string
+}{
+	{"ACME      00012.50NY", []Column{{0, 10}, {10, 18}, {18, -1}}, []string{"ACME", "00012.50", "NY"}},
+	{"ACME      00012.50", []Column{{0, 10}, {10, 18}, {18, -1}}, []string{"ACME", "00012.50"}},
+	{"ACME", []Column{{0, 10}, {10, 18}}, []string{"ACME"}},
+	{"ACME  xx  12", []Column{{0, 4}, {10, 12}}, []string{"ACME", "12"}},
+	{"かど  x", []Column{{0, 6}, {6, -1}}, []string{"かど", "x"}},
+	{"", []Column{{0, 4}}, []string{}},
+}
+
+func TestSplitColumns(t *testing.T) {
+	for _, tt := range splitColumnsCases {
+		a := NewAlign(strings.NewReader(""), &bytes.Buffer{}, ",", TextQualifier{})
+		a.SplitColumns(tt.cols...)
+
+		words, _ := a.split(tt.input)
+		if strings.Join(words, "|") != strings.Join(tt.expected, "|") || len(words) != len(tt.expected) {
+			t.Fatalf("split(%q) = %q; want %q", tt.input, words, tt.expected)
+		}
+	}
+}
+
+func TestSplitWidths(t *testing.T) {
+	a := NewAlign(strings.NewReader(""), &bytes.Buffer{}, ",", TextQualifier{})
+	a.SplitWidths(4, 3)
+
+	words, _ := a.split("abcd123rest of line")
+	if expected := []string{"abcd", "123", "rest of line"}; strings.Join(words, "|") != strings.Join(expected, "|") {
+		t.Fatalf("split() = %q; want %q", words, expected)
+	}
+}
+
+func TestExportColumns(t *testing.T) {
+	input := `ACME CORP 0001250NY
+BOB       0000003CA`
+
+	out := &bytes.Buffer{}
+	a := NewAlign(strings.NewReader(input), out, ",", TextQualifier{})
+	a.SplitWidths(10, 7)
+	a.FilterColumns([]int{1, 2})
+	a.UpdatePadding(PaddingOpts{Justification: JustifyLeft, ColumnOverride: map[int]Justification{2: JustifyRight}, Pad: 0})
+
+	if err := a.Align(); err != nil {
+		t.Fatalf("Align() = %v; want nil", err)
+	}
+
+	expected := "ACME CORP,0001250\nBOB      ,0000003\n"
+	if got := out.String(); got != expected {
+		t.Fatalf("Align() = %q; want %q", got, expected)
+	}
+}
+
+func TestInferColumns(t *testing.T) {
+	input := `ID  NAME       AMOUNT
+1   Alice          10
+22  Bob Smith     250
+333 Carol           5`
+
+	for _, twoPass := range []bool{false, true} {
+		out := &bytes.Buffer{}
+		a := NewAlign(strings.NewReader(input), out, "|", TextQualifier{})
+		a.TwoPass(twoPass)
+		a.SplitColumns()
+
+		if err := a.Align(); err != nil {
+			t.Fatalf("Align() = %v; want nil", err)
+		}
+
+		expected := `ID  | NAME      | AMOUNT 
+1   | Alice     | 10     
+22  | Bob Smith | 250    
+333 | Carol     | 5      
+`
+		if got := out.String(); got != expected {
+			t.Fatalf("Align() with TwoPass(%v) = \n%v; want\n%v", twoPass, got, expected)
+		}
+	}
+}
+
+func TestStreamInferColumns(t *testing.T) {
+	input := "a  b\ncc d\neee  f"
+
+	out := &bytes.Buffer{}
+	a := NewAlign(strings.NewReader(input), out, "|", TextQualifier{})
+	a.SplitColumns()
+	a.UpdateStream(StreamOpts{Lines: 2, Overflow: OverflowGrow})
+
+	if err := a.Align(); err != nil {
+		t.Fatalf("Align() = %v; want nil", err)
+	}
+
+	// the columns are planned from the first two lines
+	expected := "a  | b \ncc | d \nee | f \n"
+	if got := out.String(); got != expected {
+		t.Fatalf("Align() = %q; want %q", got, expected)
+	}
+}
@@ -0,0 +1,155 @@
+package align
+
+import "strings"
+
+// Format determines how the aligned fields are written to the output.
+type Format byte
+
+// Text, Table, Markdown or HTML Format options.
+const (
+	FormatText     Format = iota + 1 // fields joined by the output separator
+	FormatTable                      // a table drawn with the Style set by TableStyle
+	FormatMarkdown                   // a GitHub Flavored Markdown table
+	FormatHTML                       // an HTML table, configured by UpdateHTML
+)
+
+// OutputFormat sets how the aligned fields are written to the output.  The default is FormatText.
+// In the other formats, the header lines set by UpdateHeader (or else the first line) are the header
+// of the table, the output separator is not used, and the fields of short lines are written as empty cells.
+func (a *Align) OutputFormat(f Format) {
+	a.format = f
+}
+
+// justification returns the Justification of the column numbered columnNum.
+func (a *Align) justification(columnNum int) Justification {
+	j := a.padOpts.Justification
+
+	// override Justification for the specified columnNum in the key for the PaddingOpts.columnOverride map
+	if len(a.padOpts.ColumnOverride) > 0 {
+		for k, v := range a.padOpts.ColumnOverride {
+			if k == columnNum+1 {
+				j = v
+			}
+		}
+	}
+	return a.resolve(j, columnNum)
+}
+
+// prepare returns word as it is written to the column numbered columnNum.
+func (a *Align) prepare(word string, columnNum int) string {
+	if a.widthOpts.ExpandTabs {
+		word = a.widthOpts.expandTabs(word, a.starts[columnNum])
+	}
+
+	if a.streamOpts.Lines > 0 && a.streamOpts.Overflow == OverflowTruncate {
+		word = truncateField(word, a.columnSize(columnNum), a.starts[columnNum], a.widthOpts)
+	}
+
+	if a.widthOpts.ANSI {
+		word = closeEscapes(word)
+	}
+	return word
+}
+
+// justify returns word padded to width, in the Justification of the column numbered columnNum
+// in the line numbered lineNum.
+func (a *Align) justify(word string, lineNum, columnNum, width int) string {
+	word, padLength, j := a.fit(word, columnNum, width, a.lineJustification(lineNum, columnNum))
+	s := string(applyPadding(a.padder, word, "", 0, padLength, j))
+	a.padder.Reset()
+	return s
+}
+
+// fit returns the length of the padding that fills width cells of the column numbered columnNum with word,
+// to be written in Justification j.  Moving a word containing tabs changes the width of its tabs, so such
+// a word is returned with the padding written before it, to be written left justified, and any padding
+// its tabs cannot absorb is written after it.
+func (a *Align) fit(word string, columnNum, width int, j Justification) (string, int, Justification) {
+	if !strings.Contains(word, "\t") {
+		return word, countPadding(word, width, a.widthOpts), j
+	}
+
+	start := a.starts[columnNum]
+	padLength := width - a.widthOpts.widthAt(word, start)
+
+	var before int
+	switch j {
+	case JustifyRight:
+		before = padLength
+	case JustifyCenter:
+		if padLength > 2 {
+			before = padLength - padLength/2
+		}
+	}
+	for before > 0 && before+a.widthOpts.widthAt(word, start+before) > width {
+		before--
+	}
+	return strings.Repeat(string(padchar), before) + word, width - before - a.widthOpts.widthAt(word, start+before), JustifyLeft
+}
+
+// outputColumns returns the numbers of the columns written to a table, in order.
+func (a *Align) outputColumns() []int {
+	cols := make([]int, 0, len(a.columnCounts))
+	if a.filterLen > 0 {
+		for _, c := range a.filter {
+			if _, ok := a.columnCounts[c-1]; ok {
+				cols = append(cols, c-1)
+			}
+		}
+		return cols
+	}
+
+	for c := 0; c < len(a.columnCounts); c++ {
+		cols = append(cols, c)
+	}
+	return cols
+}
+
+// writeRow writes the fields of a line numbered lineNum in the Align's Format.
+func (a *Align) writeRow(lineNum int, words []string) error {
+	var sb strings.Builder
+	switch a.format {
+	case FormatTable:
+		a.tableRow(&sb, lineNum, words)
+	case FormatMarkdown:
+		a.markdownRow(&sb, lineNum, words)
+	case FormatHTML:
+		a.htmlRow(&sb, lineNum, words)
+	}
+	a.lastRow = lineNum
+
+	if _, err := a.writer.WriteString(sb.String()); err != nil {
+		return &WriteError{Line: lineNum, Err: err}
+	}
+	return nil
+}
+
+// finishRows writes the end of the table, if any rows were written in a Format other than FormatText.
+func (a *Align) finishRows() error {
+	if a.lastRow == 0 {
+		return nil
+	}
+
+	var sb strings.Builder
+	switch a.format {
+	case FormatTable:
+		a.tableRule(&sb, a.tableStyle.Bottom)
+	case FormatMarkdown:
+		if a.lastRow < a.headerLines() {
+			// the input ended within the header
+			a.markdownRow(&sb, a.headerLines(), a.mdHeader)
+		}
+		if a.lastRow <= a.headerLines() {
+			// a table must have a delimiter row, even without any other rows
+			a.markdownDelimiter(&sb)
+		}
+	case FormatHTML:
+		a.htmlEnd(&sb)
+	}
+	a.lastRow = 0
+
+	if _, err := a.writer.WriteString(sb.String()); err != nil {
+		return &WriteError{Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package align
+
+import "strings"
+
+// HeaderOpts provides configurability for the header lines at the start of the input.
+type HeaderOpts struct {
+	Lines         int           // number of header lines
+	Justification Justification // Justification of the header fields; 0 to justify them like the other lines
+	Rule          string        // underlines the header at the column widths, e.g. "-" or "="; "" for none
+	Repeat        int           // write the header again before every Repeat lines that follow it; 0 for never
+}
+
+// UpdateHeader uses HeaderOpts h to update the Align's header options.
+// The header Justification applies to every header field, regardless of the PaddingOpts ColumnOverride.
+// The Rule is only drawn with FormatText, as the other formats draw a rule of their own after the header,
+// and the header is only repeated with FormatText and FormatTable.
+// Without a header, the first line is the header of the tables written in the other formats.
+// As a Markdown table has a single header row, the fields of the header lines are joined with <br> in FormatMarkdown.
+func (a *Align) UpdateHeader(h HeaderOpts) {
+	a.headerOpts = h
+}
+
+// headerLines returns the number of header lines.
+func (a *Align) headerLines() int {
+	if a.headerOpts.Lines > 0 {
+		return a.headerOpts.Lines
+	}
+	if a.format > FormatText {
+		return 1
+	}
+	return 0
+}
+
+// isHeader reports whether the line numbered lineNum is a header line.
+func (a *Align) isHeader(lineNum int) bool {
+	return lineNum <= a.headerLines()
+}
+
+// lineJustification returns the Justification of the column numbered columnNum in the line numbered lineNum.
+func (a *Align) lineJustification(lineNum, columnNum int) Justification {
+	if a.headerOpts.Justification != 0 && a.isHeader(lineNum) {
+		return a.resolve(a.headerOpts.Justification, columnNum)
+	}
+	return a.justification(columnNum)
+}
+
+// writeHeader keeps the header lines as they are exported, and exports them again
+// before every Repeat lines that follow them.
+func (a *Align) writeHeader(lineNum int, line, surroundingPad string) error {
+	n := a.headerOpts.Lines
+	if n <= 0 {
+		return nil
+	}
+
+	if lineNum <= n {
+		if lineNum == 1 {
+			a.headers = a.headers[:0]
+		}
+		a.headers = append(a.headers, line)
+		return nil
+	}
+
+	k := a.headerOpts.Repeat
+	if k <= 0 || a.format > FormatTable || (lineNum-n-1)%k != 0 || lineNum == n+1 {
+		return nil
+	}
+
+	headers := append([]string(nil), a.headers...)
+	for i, header := range headers {
+		if err := a.exportLine(i+1, header, surroundingPad); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeHeaderRule underlines the header with the Rule after its last line numbered lineNum.
+func (a *Align) writeHeaderRule(lineNum int, surroundingPad string) error {
+	if a.headerOpts.Rule == "" || lineNum != a.headerOpts.Lines || a.format > FormatText {
+		return nil
+	}
+
+	w := a.widthOpts.width(a.headerOpts.Rule)
+	if w < 1 {
+		w = 1
+	}
+
+	rule := make([]string, len(a.columnCounts))
+	for c := range rule {
+		rule[c] = strings.Repeat(a.headerOpts.Rule, a.columnCounts[c]/w)
+	}
+
+	var seps []string
+	if a.keepSeps {
+		// the separators found in the input are left blank, at the width of the widest one at each boundary
+		seps = make([]string, len(a.sepCounts))
+		for c := range seps {
+			seps[c] = strings.Repeat(string(padchar), a.sepCounts[c])
+		}
+	}
+	return a.writeWords(lineNum, rule, seps, surroundingPad)
+}
@@ -0,0 +1,156 @@
+package align
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestHeaderRule(t *testing.T) {
+	input := "name,qty\napple,10\nkiwi,5"
+
+	out := &bytes.Buffer{}
+	a := NewAlign(strings.NewReader(input), out, ",", TextQualifier{})
+	a.UpdatePadding(PaddingOpts{Justification: JustifyLeft, ColumnOverride: map[int]Justification{2: JustifyRight}, Pad: 1})
+	a.UpdateHeader(HeaderOpts{Lines: 1, Justification: JustifyCenter, Rule: "="})
+
+	if err := a.Align(); err != nil {
+		t.Fatalf("Align() = %v; want nil", err)
+	}
+
+	expected := `name  , qty 
+===== , === 
+apple ,  10 
+kiwi  ,   5 
+`
+	if got := out.String(); got != expected {
+		t.Fatalf("Align() = \n%v; want\n%v", got, expected)
+	}
+}
+
+func TestHeaderRuleKeepSeparators(t *testing.T) {
+	var cases = []struct {
+		input    string
+		split    func(a *Align)
+		expected string
+	}{
+		{
+			"key => value\na => 1",
+			func(a *Align) { a.SplitRegexp(regexp.MustCompile(`\s*=>\s*`)) },
+			"key => value \n---    ----- \na   => 1     \n",
+		},
+		{
+			"x := 1\nfoo = 2\nb += 3",
+			func(a *Align) { a.SplitTokens(":=", "=", "+=") },
+			"x   := 1 \n---    - \nfoo  = 2 \nb   += 3 \n",
+		},
+	}
+
+	for _, tt := range cases {
+		out := &bytes.Buffer{}
+		a := NewAlign(strings.NewReader(tt.input), out, ",", TextQualifier{})
+		tt.split(a)
+		a.KeepSeparators(true)
+		a.UpdateHeader(HeaderOpts{Lines: 1, Rule: "-"})
+
+		if err := a.Align(); err != nil {
+			t.Fatalf("Align() = %v; want nil", err)
+		}
+		if got := out.String(); got != tt.expected {
+			t.Fatalf("Align(%q) = %q; want %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestHeaderJustification(t *testing.T) {
+	input := "id,description\n1,first item"
+
+	out := &bytes.Buffer{}
+	a := NewAlign(strings.NewReader(input), out, ",", TextQualifier{})
+	a.UpdatePadding(PaddingOpts{Justification: JustifyLeft, ColumnOverride: map[int]Justification{1: JustifyRight}, Pad: 1})
+	a.UpdateHeader(HeaderOpts{Lines: 1, Justification: JustifyCenter})
+
+	if err := a.Align(); err != nil {
+		t.Fatalf("Align() = %v; want nil", err)
+	}
+
+	expected := "id , description \n 1 , first item  \n"
+	if got := out.String(); got != expected {
+		t.Fatalf("Align() = %q; want %q", got, expected)
+	}
+}
+
+func TestHeaderRepeat(t *testing.T) {
+	input := "h\n1\n2\n3\n4\n5"
+
+	out := &bytes.Buffer{}
+	a := NewAlign(strings.NewReader(input), out, ",", TextQualifier{})
+	a.UpdateHeader(HeaderOpts{Lines: 1, Rule: "-", Repeat: 2})
+
+	if err := a.Align(); err != nil {
+		t.Fatalf("Align() = %v; want nil", err)
+	}
+
+	expected := "h \n- \n1 \n2 \nh \n- \n3 \n4 \nh \n- \n5 \n"
+	if got := out.String(); got != expected {
+		t.Fatalf("Align() = %q; want %q", got, expected)
+	}
+}
+
+func TestHeaderRepeatTable(t *testing.T) {
+	input := "a,b\nc,d\n1,2\n3,4\n5,6"
+
+	out := &bytes.Buffer{}
+	a := NewAlign(strings.NewReader(input), out, ",", TextQualifier{})
+	a.OutputFormat(FormatTable)
+	a.UpdateHeader(HeaderOpts{Lines: 2, Repeat: 2})
+
+	if err := a.Align(); err != nil {
+		t.Fatalf("Align() = %v; want nil", err)
+	}
+
+	expected := `+---+---+
+| a | b |
+| c | d |
++---+---+
+| 1 | 2 |
+| 3 | 4 |
++---+---+
+| a | b |
+| c | d |
++---+---+
+| 5 | 6 |
++---+---+
+`
+	if got := out.String(); got != expected {
+		t.Fatalf("Align() = \n%v; want\n%v", got, expected)
+	}
+}
+
+func TestHeaderHTML(t *testing.T) {
+	input := "a\nb\nc"
+
+	out := &bytes.Buffer{}
+	a := NewAlign(strings.NewReader(input), out, ",", TextQualifier{})
+	a.OutputFormat(FormatHTML)
+	a.UpdateHeader(HeaderOpts{Lines: 2, Justification: JustifyCenter, Repeat: 1})
+
+	if err := a.Align(); err != nil {
+		t.Fatalf("Align() = %v; want nil", err)
+	}
+
+	expected := `<table>
+<thead>
+<tr><th style="text-align: center">a</th></tr>
+<tr><th style="text-align: center">b</th></tr>
+</thead>
+<tbody>
+<tr><td style="text-align: left">c</td></tr>
+</tbody>
+</table>
+`
+	if got := out.String(); got != expected {
+		t.Fatalf("Align() = \n%v; want\n%v", got, expected)
+	}
+}
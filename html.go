@@ -0,0 +1,102 @@
+package align
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// HTMLOpts provides configurability for the tables written with FormatHTML.
+type HTMLOpts struct {
+	Page  bool   // write a complete HTML page instead of a fragment with just the table
+	Title string // title of the page
+}
+
+// UpdateHTML uses HTMLOpts h to update the Align's HTML options.
+// The header lines are written as the table's <thead>, and the rest as its <tbody>.
+// Each cell is aligned by a text-align style from the Justification of its column.
+func (a *Align) UpdateHTML(h HTMLOpts) {
+	a.htmlOpts = h
+}
+
+// htmlPageStart and htmlPageEnd surround the table of a complete HTML page.
+const (
+	htmlPageStart = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>%s</title>
+<style>
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 2px 8px; }
+</style>
+</head>
+<body>
+`
+	htmlPageEnd = `</body>
+</html>
+`
+)
+
+// htmlEscape escapes s, and replaces its line breaks with <br>, so that it can be written as a cell of an HTML table.
+func htmlEscape(s string) string {
+	s = html.EscapeString(s)
+	if strings.ContainsAny(s, "\r\n") {
+		s = strings.ReplaceAll(s, "\r\n", "<br>")
+		s = strings.ReplaceAll(s, "\n", "<br>")
+	}
+	return s
+}
+
+// htmlAlign returns the text-align style of the column numbered columnNum in the line numbered lineNum.
+func (a *Align) htmlAlign(lineNum, columnNum int) string {
+	switch a.lineJustification(lineNum, columnNum) {
+	case JustifyRight:
+		return "right"
+	case JustifyCenter:
+		return "center"
+	}
+	return "left"
+}
+
+// htmlRow writes the fields of the line numbered lineNum as a row of an HTML table,
+// starting the table before the first line and its body after the header.
+func (a *Align) htmlRow(sb *strings.Builder, lineNum int, words []string) {
+	switch {
+	case a.lastRow == 0:
+		if a.htmlOpts.Page {
+			fmt.Fprintf(sb, htmlPageStart, html.EscapeString(a.htmlOpts.Title))
+		}
+		sb.WriteString("<table>\n<thead>\n")
+	case lineNum != a.lastRow && a.lastRow == a.headerLines():
+		sb.WriteString("</thead>\n<tbody>\n")
+	}
+
+	cell := "td"
+	if a.isHeader(lineNum) {
+		cell = "th"
+	}
+
+	sb.WriteString("<tr>")
+	for _, c := range a.outputColumns() {
+		var word string
+		if c < len(words) {
+			word = words[c]
+		}
+		sb.WriteString("<" + cell + ` style="text-align: ` + a.htmlAlign(lineNum, c) + `">` + word + "</" + cell + ">")
+	}
+	sb.WriteString("</tr>\n")
+}
+
+// htmlEnd closes an HTML table, and its page if it has one.
+func (a *Align) htmlEnd(sb *strings.Builder) {
+	if a.lastRow <= a.headerLines() {
+		sb.WriteString("</thead>\n")
+	} else {
+		sb.WriteString("</tbody>\n")
+	}
+	sb.WriteString("</table>\n")
+	if a.htmlOpts.Page {
+		sb.WriteString(htmlPageEnd)
+	}
+}
@@ -0,0 +1,87 @@
+package align
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestHTMLEscape(t *testing.T) {
+	if got, expected := htmlEscape("<a & b>\nc"), "&lt;a &amp; b&gt;<br>c"; got != expected {
+		t.Fatalf("htmlEscape() = %q; want %q", got, expected)
+	}
+}
+
+func TestExportHTML(t *testing.T) {
+	input := "name,qty\n<b>apple</b>,10\nkiwi"
+
+	out := &bytes.Buffer{}
+	a := NewAlign(strings.NewReader(input), out, ",", TextQualifier{})
+	a.UpdatePadding(PaddingOpts{Justification: JustifyLeft, ColumnOverride: map[int]Justification{2: JustifyRight}, Pad: 1})
+	a.OutputFormat(FormatHTML)
+
+	if err := a.Align(); err != nil {
+		t.Fatalf("Align() = %v; want nil", err)
+	}
+
+	expected := `<table>
+<thead>
+<tr><th style="text-align: left">name</th><th style="text-align: right">qty</th></tr>
+</thead>
+<tbody>
+<tr><td style="text-align: left">&lt;b&gt;apple&lt;/b&gt;</td><td style="text-align: right">10</td></tr>
+<tr><td style="text-align: left">kiwi</td><td style="text-align: right"></td></tr>
+</tbody>
+</table>
+`
+	if got := out.String(); got != expected {
+		t.Fatalf("Align() = \n%v; want\n%v", got, expected)
+	}
+}
+
+func TestExportHTMLQualified(t *testing.T) {
+	input := "fruit\n\"banana, ripe\""
+
+	var cases = []struct {
+		quoting  Quoting
+		expected string
+	}{
+		{0, `<td style="text-align: left">banana, ripe</td>`},
+		{QuoteKeep, `<td style="text-align: left">&#34;banana, ripe&#34;</td>`},
+	}
+
+	for _, tt := range cases {
+		out := &bytes.Buffer{}
+		a := NewAlign(strings.NewReader(input), out, ",", TextQualifier{On: true, Qualifier: `"`, CSV: true})
+		a.OutputFormat(FormatHTML)
+		a.OutputQuoting(tt.quoting)
+
+		if err := a.Align(); err != nil {
+			t.Fatalf("Align() = %v; want nil", err)
+		}
+		if got := out.String(); !strings.Contains(got, tt.expected) {
+			t.Fatalf("Align() with Quoting %v = \n%v; want it to contain %v", tt.quoting, got, tt.expected)
+		}
+	}
+}
+
+func TestExportHTMLPage(t *testing.T) {
+	out := &bytes.Buffer{}
+	a := NewAlign(strings.NewReader("a,b,c"), out, ",", TextQualifier{})
+	a.OutputFormat(FormatHTML)
+	a.UpdateHTML(HTMLOpts{Page: true, Title: "Q&A"})
+	a.FilterColumns([]int{3})
+
+	if err := a.Align(); err != nil {
+		t.Fatalf("Align() = %v; want nil", err)
+	}
+
+	got := out.String()
+	if !strings.HasPrefix(got, "<!DOCTYPE html>\n") || !strings.Contains(got, "<title>Q&amp;A</title>") {
+		t.Fatalf("Align() = \n%v; want a page titled Q&amp;A", got)
+	}
+	table := "<table>\n<thead>\n<tr><th style=\"text-align: left\">c</th></tr>\n</thead>\n</table>\n</body>\n</html>\n"
+	if !strings.HasSuffix(got, table) {
+		t.Fatalf("Align() = \n%v; want it to end with\n%v", got, table)
+	}
+}
@@ -0,0 +1,90 @@
+package align
+
+import "strings"
+
+// markdownEscape escapes the pipes in s, and replaces its line breaks with <br>,
+// so that it can be written as a cell of a Markdown table.
+func markdownEscape(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	if strings.ContainsAny(s, "\r\n") {
+		s = strings.ReplaceAll(s, "\r\n", "<br>")
+		s = strings.ReplaceAll(s, "\n", "<br>")
+	}
+	return s
+}
+
+// markdownWidth returns the width of the column numbered columnNum in a Markdown table,
+// which is wide enough for the colons of its delimiter.
+func (a *Align) markdownWidth(columnNum int) int {
+	if w := a.columnCounts[columnNum]; w > 3 {
+		return w
+	}
+	return 3
+}
+
+// markdownHeader returns the fields of the header lines up to the line numbered lineNum joined with <br>,
+// as a Markdown table has a single header row.  The fields of any other line are returned unchanged.
+func (a *Align) markdownHeader(lineNum int, words []string) []string {
+	if a.format != FormatMarkdown || a.headerLines() < 2 || !a.isHeader(lineNum) {
+		return words
+	}
+
+	if lineNum == 1 {
+		a.mdHeader = a.mdHeader[:0]
+	}
+	for i := range a.mdHeader {
+		a.mdHeader[i] += "<br>"
+	}
+	for i, word := range words {
+		if i < len(a.mdHeader) {
+			a.mdHeader[i] += word
+		} else {
+			a.mdHeader = append(a.mdHeader, strings.Repeat("<br>", lineNum-1)+word)
+		}
+	}
+	return append([]string(nil), a.mdHeader...)
+}
+
+// markdownRow writes the fields of the line numbered lineNum as a row of a Markdown table,
+// after the delimiter row that follows the header.  The header row is written with the last header line.
+func (a *Align) markdownRow(sb *strings.Builder, lineNum int, words []string) {
+	if lineNum < a.headerLines() {
+		return
+	}
+	if lineNum != a.lastRow && a.lastRow == a.headerLines() {
+		a.markdownDelimiter(sb)
+	}
+
+	pad := strings.Repeat(string(padchar), a.padOpts.Pad)
+	sb.WriteByte('|')
+	for _, c := range a.outputColumns() {
+		var word string
+		if c < len(words) {
+			word = a.prepare(words[c], c)
+		}
+		sb.WriteString(pad + a.justify(word, lineNum, c, a.markdownWidth(c)) + pad + "|")
+	}
+	sb.WriteByte('\n')
+}
+
+// markdownDelimiter writes the delimiter row of a Markdown table, which sets the alignment of each column
+// from its Justification.
+func (a *Align) markdownDelimiter(sb *strings.Builder) {
+	pad := strings.Repeat(string(padchar), a.padOpts.Pad)
+	sb.WriteByte('|')
+	for _, c := range a.outputColumns() {
+		w := a.markdownWidth(c)
+
+		var marker string
+		switch a.justification(c) {
+		case JustifyRight:
+			marker = strings.Repeat("-", w-1) + ":"
+		case JustifyCenter:
+			marker = ":" + strings.Repeat("-", w-2) + ":"
+		default:
+			marker = ":" + strings.Repeat("-", w-1)
+		}
+		sb.WriteString(pad + marker + pad + "|")
+	}
+	sb.WriteByte('\n')
+}
@@ -0,0 +1,138 @@
+package align
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestMarkdownEscape(t *testing.T) {
+	if got, expected := markdownEscape("a|b\r\nc\nd"), `a\|b<br>c<br>d`; got != expected {
+		t.Fatalf("markdownEscape() = %q; want %q", got, expected)
+	}
+}
+
+func TestExportMarkdown(t *testing.T) {
+	input := `name,qty,note
+apple,10,a|b
+kiwi,5
+fig,1,"x
+y"`
+
+	out := &bytes.Buffer{}
+	a := NewAlign(strings.NewReader(input), out, ",", TextQualifier{On: true, Qualifier: `"`, CSV: true, Multiline: true})
+	a.UpdatePadding(PaddingOpts{
+		Justification:  JustifyLeft,
+		ColumnOverride: map[int]Justification{2: JustifyRight, 3: JustifyCenter},
+		Pad:            1,
+	})
+	a.OutputFormat(FormatMarkdown)
+	a.OutputQuoting(QuoteStrip)
+
+	if err := a.Align(); err != nil {
+		t.Fatalf("Align() = %v; want nil", err)
+	}
+
+	expected := `| name  | qty | note   |
+| :---- | --: | :----: |
+| apple |  10 | a\|b   |
+| kiwi  |   5 |        |
+| fig   |   1 | x<br>y |
+`
+	if got := out.String(); got != expected {
+		t.Fatalf("Align() = \n%v; want\n%v", got, expected)
+	}
+}
+
+func TestExportMarkdownQualified(t *testing.T) {
+	input := "fruit,qty\n\"banana, ripe\",1"
+
+	var cases = []struct {
+		quoting  Quoting
+		expected string
+	}{
+		{0, "| fruit        | qty |\n| :----------- | :-- |\n| banana, ripe | 1   |\n"},
+		{QuoteKeep, "| fruit          | qty |\n| :------------- | :-- |\n| \"banana, ripe\" | 1   |\n"},
+	}
+
+	for _, tt := range cases {
+		out := &bytes.Buffer{}
+		a := NewAlign(strings.NewReader(input), out, ",", TextQualifier{On: true, Qualifier: `"`, CSV: true})
+		a.OutputFormat(FormatMarkdown)
+		a.OutputQuoting(tt.quoting)
+
+		if err := a.Align(); err != nil {
+			t.Fatalf("Align() = %v; want nil", err)
+		}
+		if got := out.String(); got != tt.expected {
+			t.Fatalf("Align() with Quoting %v = %q; want %q", tt.quoting, got, tt.expected)
+		}
+	}
+}
+
+func TestExportMarkdownHeaderLines(t *testing.T) {
+	var cases = []struct {
+		input    string
+		expected string
+	}{
+		{
+			"name,qty\n(fruit)\napple,10",
+			"| name<br>(fruit) | qty<br> |\n| :-------------- | :------ |\n| apple           | 10      |\n",
+		},
+		{
+			"name,qty",
+			"| name | qty |\n| :--- | :-- |\n",
+		},
+	}
+
+	for _, tt := range cases {
+		out := &bytes.Buffer{}
+		a := NewAlign(strings.NewReader(tt.input), out, ",", TextQualifier{})
+		a.OutputFormat(FormatMarkdown)
+		a.UpdateHeader(HeaderOpts{Lines: 2})
+
+		if err := a.Align(); err != nil {
+			t.Fatalf("Align() = %v; want nil", err)
+		}
+		if got := out.String(); got != tt.expected {
+			t.Fatalf("Align(%q) = %q; want %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestExportMarkdownFilter(t *testing.T) {
+	input := "a,b,c\nd,e,f"
+
+	out := &bytes.Buffer{}
+	a := NewAlign(strings.NewReader(input), out, ",", TextQualifier{})
+	a.OutputFormat(FormatMarkdown)
+	a.FilterColumns([]int{1, 3})
+
+	if err := a.Align(); err != nil {
+		t.Fatalf("Align() = %v; want nil", err)
+	}
+
+	expected := `| a   | c   |
+| :-- | :-- |
+| d   | f   |
+`
+	if got := out.String(); got != expected {
+		t.Fatalf("Align() = \n%v; want\n%v", got, expected)
+	}
+}
+
+func TestExportMarkdownHeaderOnly(t *testing.T) {
+	out := &bytes.Buffer{}
+	a := NewAlign(strings.NewReader("a,b"), out, ",", TextQualifier{})
+	a.OutputFormat(FormatMarkdown)
+	a.UpdatePadding(PaddingOpts{Justification: JustifyRight, Pad: 0})
+
+	if err := a.Align(); err != nil {
+		t.Fatalf("Align() = %v; want nil", err)
+	}
+
+	expected := "|  a|  b|\n|--:|--:|\n"
+	if got := out.String(); got != expected {
+		t.Fatalf("Align() = %q; want %q", got, expected)
+	}
+}
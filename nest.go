@@ -0,0 +1,127 @@
+package align
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// NestOpts provides configurability for splitting code, where the separators within brackets
+// or string literals do not split a field.
+type NestOpts struct {
+	Open   string // opening brackets, e.g. "([{"
+	Close  string // closing brackets, e.g. ")]}"
+	Quotes string // characters that begin and end a string literal, e.g. "\"'`"
+	Escape rune   // escapes the following character within a string literal; 0 for none
+}
+
+// DefaultNest splits code with parentheses, square and curly brackets, and Go or C style string literals.
+var DefaultNest = NestOpts{
+	Open:   "([{",
+	Close:  ")]}",
+	Quotes: "\"'`",
+	Escape: '\\',
+}
+
+// UpdateNest uses NestOpts n to update the Align's nesting options.  When any brackets or quotes
+// are set, a separator only splits a line at the top level, outside of any brackets or string literals.
+// A line that is wrapped in brackets, such as a Go test case {"a", f(1, 2)}, is split within them.
+// The brackets are counted by depth, so any closing bracket closes the last opening bracket.
+// A string literal or bracket left open at the end of a line does not continue on the next line.
+// The TextQualifier and EscapeOpts do not apply while nesting.
+func (a *Align) UpdateNest(n NestOpts) {
+	a.nestOpts = n
+}
+
+// on reports whether any brackets or quotes are set.
+func (n NestOpts) on() bool {
+	return n.Open != "" || n.Quotes != ""
+}
+
+// topLevel reports which bytes of s are at the top level.
+func (n NestOpts) topLevel(s string) []bool {
+	depths := make([]int, len(s)) // -1 within a string literal
+	firstClose := -1              // end of the bracket that first returns to depth 0
+
+	var depth int
+	var quote rune
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		d := depth
+		switch {
+		case quote != 0:
+			d = -1
+			if r == n.Escape && i+size < len(s) {
+				_, next := utf8.DecodeRuneInString(s[i+size:])
+				size += next
+			} else if r == quote {
+				quote = 0
+			}
+		case strings.ContainsRune(n.Quotes, r):
+			d, quote = -1, r
+		case strings.ContainsRune(n.Open, r):
+			depth++
+		case strings.ContainsRune(n.Close, r) && depth > 0:
+			depth--
+			d = depth
+			if depth == 0 && firstClose == -1 {
+				firstClose = i + size
+			}
+		}
+
+		for j := i; j < i+size; j++ {
+			depths[j] = d
+		}
+		i += size
+	}
+
+	// a line wrapped in brackets, optionally followed by a comma or semicolon, is split within them
+	var base int
+	trimmed := strings.TrimLeft(s, " \t")
+	if r, _ := utf8.DecodeRuneInString(trimmed); trimmed != "" && strings.ContainsRune(n.Open, r) {
+		if firstClose == -1 || strings.Trim(s[firstClose:], ",; \t") == "" {
+			base = 1
+		}
+	}
+
+	top := make([]bool, len(s))
+	for i, d := range depths {
+		top[i] = d == base
+	}
+	return top
+}
+
+// splitNested works in the same way as split, but only splits s at the top level.
+func (a *Align) splitNested(s string) (words, seps []string) {
+	if _, ok := a.delim.(blanks); ok {
+		s = strings.Trim(s, " \t")
+	}
+	top := a.nestOpts.topLevel(s)
+
+	for start, from := 0, 0; ; {
+		if a.maxSplit > 0 && len(words) == a.maxSplit {
+			return append(words, s[start:]), seps
+		}
+
+		var i, n int
+		if a.delim == nil {
+			i, n = strings.Index(s[from:], a.sep), len(a.sep)
+		} else {
+			i, n = a.delim.index(s[from:], len(words))
+		}
+		if i == -1 || n == 0 {
+			return append(words, s[start:]), seps
+		}
+
+		at := from + i
+		if !top[at] {
+			// look for the next separator after the start of this one
+			_, size := utf8.DecodeRuneInString(s[at:])
+			from = at + size
+			continue
+		}
+
+		words = append(words, s[start:at])
+		seps = append(seps, s[at:at+n])
+		start, from = at+n, at+n
+	}
+}
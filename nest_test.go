@@ -0,0 +1,73 @@
+package align
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+var splitNestedCases = []struct {
+	input    string
+	expected []string
+}{
+	{`a, f(b, c), d`, []string{"a", " f(b, c)", " d"}},
+	{`x, [1, {2, 3}], "y, z"`, []string{"x", " [1, {2, 3}]", ` "y, z"`}},
+	{`"a\", b", c`, []string{`"a\", b"`, " c"}},
+	{`'a, b' , c`, []string{`'a, b' `, " c"}},
+	{`	{"a, b", 1, []string{"x", "y"}},`, []string{`	{"a, b"`, " 1", ` []string{"x", "y"}},`}},
+	{`{a, b}, {c, d}`, []string{"{a, b}", " {c, d}"}},
+	{`{a, b`, []string{"{a", " b"}},
+	{`f(a, b`, []string{"f(a, b"}},
+	{`a), b`, []string{"a)", " b"}},
+	{"", []string{""}},
+}
+
+func TestSplitNested(t *testing.T) {
+	for _, tt := range splitNestedCases {
+		a := NewAlign(strings.NewReader(""), &bytes.Buffer{}, ",", TextQualifier{})
+		a.UpdateNest(DefaultNest)
+
+		words, _ := a.split(tt.input)
+		if strings.Join(words, "|") != strings.Join(tt.expected, "|") || len(words) != len(tt.expected) {
+			t.Fatalf("split(%q) = %q; want %q", tt.input, words, tt.expected)
+		}
+	}
+}
+
+func TestSplitNestedTokens(t *testing.T) {
+	a := NewAlign(strings.NewReader(""), &bytes.Buffer{}, "=", TextQualifier{})
+	a.SplitTokens(":=", "=")
+	a.UpdateNest(DefaultNest)
+
+	words, seps := a.split(`x := f(y = 1) + "=" `)
+	if expected := []string{"x", `f(y = 1) + "=" `}; strings.Join(words, "|") != strings.Join(expected, "|") {
+		t.Fatalf("split() = %q; want %q", words, expected)
+	}
+	if len(seps) != 1 || seps[0] != " := " {
+		t.Fatalf("split() seps = %q; want %q", seps, []string{" := "})
+	}
+}
+
+func TestExportNested(t *testing.T) {
+	input := `	{"a", f(1, 2), true},
+	{"longer, name", 3, false},
+	{"x", []int{4, 5, 6}, true},`
+
+	out := &bytes.Buffer{}
+	a := NewAlign(strings.NewReader(input), out, ",", TextQualifier{})
+	a.UpdatePadding(PaddingOpts{Justification: JustifyLeft, Pad: 0})
+	a.OutputSep(", ")
+	a.UpdateNest(DefaultNest)
+
+	if err := a.Align(); err != nil {
+		t.Fatalf("Align() = %v; want nil", err)
+	}
+
+	expected := `	{"a"           ,  f(1, 2)       ,  true}, 
+	{"longer, name",  3             ,  false},
+	{"x"           ,  []int{4, 5, 6},  true}, 
+`
+	if got := out.String(); got != expected {
+		t.Fatalf("Align() = \n%v; want\n%v", got, expected)
+	}
+}
@@ -0,0 +1,101 @@
+package align
+
+import "strings"
+
+// Quoting determines how the text qualifiers of the fields are written to the output.
+type Quoting byte
+
+// Keep, Strip, Always or Needed Quoting options.
+const (
+	QuoteKeep   Quoting = iota + 1 // write each field as it is in the input
+	QuoteStrip                     // remove the qualifiers for display
+	QuoteAlways                    // qualify every field
+	QuoteNeeded                    // qualify the fields that contain the output separator, the qualifier or a line break
+)
+
+// defaultQualifier qualifies the output fields if the TextQualifier does not set one.
+const defaultQualifier = `"`
+
+// OutputQuoting sets how the fields are qualified in the output.  The default is QuoteKeep, or QuoteStrip
+// with FormatMarkdown and FormatHTML, where the qualifiers are the syntax of the input rather than part of a cell.
+// Fields are unqualified according to the Align's TextQualifier, so QuoteStrip has no effect
+// unless it is On, and a doubled Qualifier within a field is only unescaped if CSV is set.
+// QuoteAlways and QuoteNeeded qualify the unqualified text of each field with the Qualifier,
+// or '"' if none is set, doubling any Qualifier within it.
+// The column widths include the qualifiers written to the output.
+func (a *Align) OutputQuoting(q Quoting) {
+	a.quoting = q
+}
+
+// fields splits s into its fields and prepares them for the output as set by OutputQuoting and OutputFormat.
+func (a *Align) fields(s string) (words, seps []string) {
+	words, seps = a.split(s)
+	quoting := a.outputQuoting()
+	if quoting == QuoteKeep && a.format != FormatMarkdown && a.format != FormatHTML {
+		return words, seps
+	}
+
+	for i, word := range words {
+		if quoting > QuoteKeep {
+			word = a.quote(word)
+		}
+		switch a.format {
+		case FormatMarkdown:
+			word = markdownEscape(word)
+		case FormatHTML:
+			word = htmlEscape(word)
+		}
+		words[i] = word
+	}
+	return words, seps
+}
+
+// outputQuoting returns the Quoting set by OutputQuoting, or the default for the Align's Format.
+func (a *Align) outputQuoting() Quoting {
+	if a.quoting != 0 {
+		return a.quoting
+	}
+	if a.format == FormatMarkdown || a.format == FormatHTML {
+		return QuoteStrip
+	}
+	return QuoteKeep
+}
+
+// quote qualifies word for the output as set by OutputQuoting.
+func (a *Align) quote(word string) string {
+	if a.txtq.On {
+		word = unquote(word, a.txtq.Qualifier, a.txtq.CSV)
+	}
+
+	qual := a.txtq.Qualifier
+	if qual == "" {
+		qual = defaultQualifier
+	}
+
+	switch a.outputQuoting() {
+	case QuoteNeeded:
+		if (a.sepOut == "" || !strings.Contains(word, a.sepOut)) &&
+			!strings.Contains(word, qual) && !strings.ContainsAny(word, "\r\n") {
+			return word
+		}
+	case QuoteStrip:
+		return word
+	}
+	return qual + strings.ReplaceAll(word, qual, qual+qual) + qual
+}
+
+// unquote removes the qual surrounding the qualified section at the start of s.
+// If csv is true, each doubled qual within it is replaced by a single qual.
+// s is returned unchanged if it does not begin with a terminated qualified section.
+func unquote(s, qual string, csv bool) string {
+	n := qualifiedLen(s, qual, csv)
+	if n == 0 || n < 2*len(qual) || !strings.HasSuffix(s[:n], qual) {
+		return s
+	}
+
+	text := s[len(qual) : n-len(qual)]
+	if csv {
+		text = strings.ReplaceAll(text, qual+qual, qual)
+	}
+	return text + s[n:]
+}
@@ -0,0 +1,79 @@
+package align
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+var unquoteCases = []struct {
+	input    string
+	csv      bool
+	expected string
+}{
+	{`"abc"`, false, "abc"},
+	{`"a""b"`, true, `a"b`},
+	{`"a""b"`, false, `a"b"`},
+	{`"abc"def`, true, "abcdef"},
+	{`"abc`, true, `"abc`},
+	{`"`, false, `"`},
+	{`abc`, false, "abc"},
+	{`a"b"`, true, `a"b"`},
+}
+
+func TestUnquote(t *testing.T) {
+	for _, tt := range unquoteCases {
+		if got := unquote(tt.input, `"`, tt.csv); got != tt.expected {
+			t.Fatalf("unquote(%q, csv %v) = %q; want %q", tt.input, tt.csv, got, tt.expected)
+		}
+	}
+}
+
+var outputQuotingCases = []struct {
+	quoting  Quoting
+	sepOut   string
+	expected string
+}{
+	{QuoteKeep, ",", "\"Smith, J\" , \"5\"\"\" , x \nplain      , b     , y \n"},
+	{QuoteStrip, "|", "Smith, J | 5\" | x \nplain    | b  | y \n"},
+	{QuoteAlways, ",", "\"Smith, J\" , \"5\"\"\" , \"x\" \n\"plain\"    , \"b\"   , \"y\" \n"},
+	{QuoteNeeded, ",", "\"Smith, J\" , \"5\"\"\" , x \nplain      , b     , y \n"},
+	{QuoteNeeded, "|", "Smith, J | \"5\"\"\" | x \nplain    | b     | y \n"},
+}
+
+func TestOutputQuoting(t *testing.T) {
+	input := "\"Smith, J\",\"5\"\"\",x\nplain,b,y"
+
+	for _, tt := range outputQuotingCases {
+		out := &bytes.Buffer{}
+		a := NewAlign(strings.NewReader(input), out, ",", TextQualifier{On: true, Qualifier: `"`, CSV: true})
+		a.OutputSep(tt.sepOut)
+		a.OutputQuoting(tt.quoting)
+
+		if err := a.Align(); err != nil {
+			t.Fatalf("Align() = %v; want nil", err)
+		}
+		if got := out.String(); got != tt.expected {
+			t.Fatalf("Align() with Quoting %v and OutputSep %q = %q; want %q", tt.quoting, tt.sepOut, got, tt.expected)
+		}
+	}
+}
+
+func TestOutputQuotingUnqualified(t *testing.T) {
+	input := "a|b,c\nd|e"
+
+	out := &bytes.Buffer{}
+	a := NewAlign(strings.NewReader(input), out, "|", TextQualifier{})
+	a.OutputSep(",")
+	a.UpdatePadding(PaddingOpts{Justification: JustifyLeft, Pad: 0})
+	a.OutputQuoting(QuoteNeeded)
+
+	if err := a.Align(); err != nil {
+		t.Fatalf("Align() = %v; want nil", err)
+	}
+
+	expected := "a,\"b,c\"\nd,e    \n"
+	if got := out.String(); got != expected {
+		t.Fatalf("Align() = %q; want %q", got, expected)
+	}
+}
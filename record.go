@@ -0,0 +1,187 @@
+package align
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+)
+
+// scanRecords is a bufio.SplitFunc that returns each record of the input, where a record
+// is a line that may continue onto the following lines within a qualified field.
+// As with bufio.ScanLines, the trailing end-of-line marker is stripped from each record.
+func (a *Align) scanRecords(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if _, ok := a.delim.(*fixedWidth); ok || a.nestOpts.on() {
+		// qualifiers are not used to split fixed-width input or code
+		return bufio.ScanLines(data, atEOF)
+	}
+	if a.delim != nil {
+		return a.scanDelimited(data, atEOF)
+	}
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+
+	sep := []byte(a.sep)
+	qual := []byte(a.txtq.Qualifier)
+
+	fieldStart := true
+	fields := 1
+	for i := 0; i < len(data); {
+		if fieldStart && len(qual) > 0 {
+			// a qualifier may be split across reads
+			if !atEOF && len(data)-i < len(qual) && bytes.HasPrefix(qual, data[i:]) {
+				return 0, nil, nil
+			}
+
+			if bytes.HasPrefix(data[i:], qual) {
+				end := a.qualifiedEnd(data[i:], qual, atEOF)
+				if end == -1 {
+					if atEOF {
+						// unterminated qualifier; the record runs to the end of the input
+						return len(data), dropCR(data), nil
+					}
+					return 0, nil, nil
+				}
+				i += end
+				fieldStart = false
+				continue
+			}
+		}
+		fieldStart = false
+
+		if data[i] == '\n' {
+			return i + 1, dropCR(data[:i]), nil
+		}
+		// the rest of the record is the last field after maxSplit separators
+		if len(sep) > 0 && bytes.HasPrefix(data[i:], sep) && (a.maxSplit <= 0 || fields <= a.maxSplit) {
+			i += len(sep)
+			fieldStart = true
+			fields++
+			continue
+		}
+		if !atEOF && len(sep) > 0 && len(data)-i < len(sep) && bytes.HasPrefix(sep, data[i:]) {
+			return 0, nil, nil
+		}
+		i++
+	}
+
+	if atEOF {
+		return len(data), dropCR(data), nil
+	}
+	return 0, nil, nil
+}
+
+// scanDelimited works like scanRecords for input split by a delimiter, continuing a record
+// onto the following line while it ends within a qualified field.
+func (a *Align) scanDelimited(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+
+	for from := 0; ; {
+		i := bytes.IndexByte(data[from:], '\n')
+		if i == -1 {
+			if atEOF {
+				// any unterminated qualifier runs to the end of the input
+				return len(data), dropCR(data), nil
+			}
+			return 0, nil, nil
+		}
+
+		end := from + i
+		if !a.openField(string(data[:end])) {
+			return end + 1, dropCR(data[:end]), nil
+		}
+		from = end + 1
+	}
+}
+
+// openField reports whether s ends within a qualified field, when it is split by the Align's delimiter.
+func (a *Align) openField(s string) bool {
+	qual := []byte(a.txtq.Qualifier)
+	if len(qual) == 0 {
+		return false
+	}
+	if _, ok := a.delim.(blanks); ok {
+		s = strings.TrimLeft(s, " \t")
+	}
+
+	for start, col := 0, 0; ; col++ {
+		if a.maxSplit > 0 && col == a.maxSplit {
+			// the rest of the record is the last field
+			return false
+		}
+
+		var q int
+		if strings.HasPrefix(s[start:], string(qual)) {
+			if q = a.qualifiedEnd([]byte(s[start:]), qual, true); q == -1 {
+				return true
+			}
+		}
+
+		i, n := a.delim.index(s[start+q:], col)
+		if i == -1 || n == 0 {
+			return false
+		}
+		start += q + i + n
+	}
+}
+
+// qualifiedEnd returns the length of the qualified section at the start of data,
+// including its closing qual, or -1 if more data is needed to find its end.
+func (a *Align) qualifiedEnd(data, qual []byte, atEOF bool) int {
+	end := len(qual)
+	for {
+		closeIdx := bytes.Index(data[end:], qual)
+		if closeIdx == -1 {
+			return -1
+		}
+		end += closeIdx + len(qual)
+
+		if !a.txtq.CSV {
+			return end
+		}
+
+		// a doubled qualifier is escaped, which can't be known until the next bytes are read
+		if !atEOF && len(data)-end < len(qual) {
+			return -1
+		}
+		if !bytes.HasPrefix(data[end:], qual) {
+			return end
+		}
+		end += len(qual)
+	}
+}
+
+// dropCR drops a terminal \r from the data.
+func dropCR(data []byte) []byte {
+	if len(data) > 0 && data[len(data)-1] == '\r' {
+		return data[0 : len(data)-1]
+	}
+	return data
+}
+
+// cellLines splits a field that spans multiple lines into its lines.
+func cellLines(s string) []string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines
+}
+
+// cellWidth returns the display width of s written at the output column col,
+// or the width of its longest line if it spans multiple lines.
+func cellWidth(s string, col int, w WidthOpts) int {
+	if !strings.Contains(s, "\n") {
+		return w.widthAt(s, col)
+	}
+
+	var max int
+	for _, line := range cellLines(s) {
+		if n := w.widthAt(line, col); n > max {
+			max = n
+		}
+	}
+	return max
+}
@@ -0,0 +1,168 @@
+package align
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+var scanRecordsCases = []struct {
+	input    string
+	sep      string
+	qual     string
+	csv      bool
+	expected []string
+}{
+	{
+		"a,b\nc,d\n",
+		",",
+		"\"",
+		false,
+		[]string{"a,b", "c,d"},
+	},
+	{
+		"a,\"b\nc\",d\r\ne,f",
+		",",
+		"\"",
+		false,
+		[]string{"a,\"b\nc\",d", "e,f"},
+	},
+	{
+		"a||'b||\nc'||d\ne",
+		"||",
+		"'",
+		false,
+		[]string{"a||'b||\nc'||d", "e"},
+	},
+	{
+		"a,\"He said \"\"hi,\nthere\"\"\",d\ne,f",
+		",",
+		"\"",
+		true,
+		[]string{"a,\"He said \"\"hi,\nthere\"\"\",d", "e,f"},
+	},
+	{
+		"a,5\" pipe,b\nc",
+		",",
+		"\"",
+		true,
+		[]string{"a,5\" pipe,b", "c"},
+	},
+	{
+		"a,\"unterminated\nb,c",
+		",",
+		"\"",
+		true,
+		[]string{"a,\"unterminated\nb,c"},
+	},
+}
+
+func TestScanRecords(t *testing.T) {
+	for _, tt := range scanRecordsCases {
+		a := NewAlign(strings.NewReader(""), &bytes.Buffer{}, tt.sep, TextQualifier{On: true, Qualifier: tt.qual, CSV: tt.csv, Multiline: true})
+
+		// read a byte at a time so that qualifiers and separators are split across reads
+		s := bufio.NewScanner(iotest.OneByteReader(strings.NewReader(tt.input)))
+		s.Split(a.scanRecords)
+
+		var got []string
+		for s.Scan() {
+			got = append(got, s.Text())
+		}
+		if err := s.Err(); err != nil {
+			t.Fatalf("scanRecords(%q) error = %v", tt.input, err)
+		}
+
+		if strings.Join(got, "|") != strings.Join(tt.expected, "|") || len(got) != len(tt.expected) {
+			t.Fatalf("scanRecords(%q) = %q; want %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+var scanDelimitedCases = []struct {
+	input    string
+	split    func(a *Align)
+	expected []string
+}{
+	{
+		"k => \"a\nb => c\" => 1\nkk => 2",
+		func(a *Align) { a.SplitRegexp(regexp.MustCompile(`\s*=>\s*`)) },
+		[]string{"k => \"a\nb => c\" => 1", "kk => 2"},
+	},
+	{
+		"x := \"a\n\"\"b\"\nyy = 2",
+		func(a *Align) { a.SplitTokens(":=", "=") },
+		[]string{"x := \"a\n\"\"b\"", "yy = 2"},
+	},
+	{
+		"  \"multi\nline\" 1\nz 2",
+		func(a *Align) { a.SplitWhitespace() },
+		[]string{"  \"multi\nline\" 1", "z 2"},
+	},
+	{
+		"ab\"c\nde\"f",
+		func(a *Align) { a.SplitWidths(1, 1) },
+		[]string{"ab\"c", "de\"f"},
+	},
+}
+
+func TestScanRecordsDelimited(t *testing.T) {
+	for _, tt := range scanDelimitedCases {
+		a := NewAlign(strings.NewReader(""), &bytes.Buffer{}, comma, TextQualifier{On: true, Qualifier: `"`, CSV: true, Multiline: true})
+		tt.split(a)
+
+		s := bufio.NewScanner(iotest.OneByteReader(strings.NewReader(tt.input)))
+		s.Split(a.scanRecords)
+
+		var got []string
+		for s.Scan() {
+			got = append(got, s.Text())
+		}
+		if err := s.Err(); err != nil {
+			t.Fatalf("scanRecords(%q) error = %v", tt.input, err)
+		}
+
+		if strings.Join(got, "|") != strings.Join(tt.expected, "|") || len(got) != len(tt.expected) {
+			t.Fatalf("scanRecords(%q) = %q; want %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestExportMultilineCells(t *testing.T) {
+	input := "id,desc,status\n1,\"line one\nline two\r\nthird\",open\n2,short,\"closed\nreally\"\n"
+
+	out := &bytes.Buffer{}
+	a := NewAlign(strings.NewReader(input), out, comma, TextQualifier{On: true, Qualifier: `"`, CSV: true, Multiline: true})
+
+	if err := a.Align(); err != nil {
+		t.Fatalf("Align() = %v; want nil", err)
+	}
+
+	expected := `id , desc      , status  
+1  , "line one , open    
+   , line two  ,         
+   , third"    ,         
+2  , short     , "closed 
+   ,           , really" 
+`
+	if got := out.String(); got != expected {
+		t.Fatalf("Align() = \n%v; want\n%v", got, expected)
+	}
+}
+
+func TestCellWidth(t *testing.T) {
+	cases := map[string]int{
+		"":                0,
+		"one":             3,
+		"one\nthree\r\nx": 5,
+		"\n":              0,
+	}
+	for in, expected := range cases {
+		if got := cellWidth(in, 0, WidthOpts{}); got != expected {
+			t.Fatalf("cellWidth(%q) = %v; want %v", in, got, expected)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package align
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// errClosed is returned when the input would be spooled after Close was called.
+var errClosed = errors.New("align: closed")
+
+// SpoolThreshold sets the number of bytes of input that may be held in memory while measuring
+// the columns of an input that cannot be read twice.  Once it is exceeded, the lines are spooled
+// to a temporary file which is streamed back when writing the output.
+// A threshold of 0 or less (the default) keeps all of the lines in memory.
+// The temporary file is removed when Align returns, or by calling Close.
+func (a *Align) SpoolThreshold(n int64) {
+	a.spoolAt = n
+}
+
+// Close removes the temporary file used to spool the input, if there is one, and prevents
+// another from being created.  It is safe to call Close from another goroutine while Align
+// is running, such as when handling an interrupt, after which Align will fail if it spools the input.
+func (a *Align) Close() error {
+	a.spoolMu.Lock()
+	a.closed = true
+	a.spoolMu.Unlock()
+
+	return a.removeSpool()
+}
+
+// removeSpool removes the temporary file used to spool the input, if there is one.
+func (a *Align) removeSpool() error {
+	a.spoolMu.Lock()
+	defer a.spoolMu.Unlock()
+
+	if a.spool == nil {
+		return nil
+	}
+
+	name := a.spool.Name()
+	a.spool.Close()
+	a.spool = nil
+
+	return os.Remove(name)
+}
+
+// hold keeps line in memory until the spool threshold is exceeded, at which point
+// all of the held lines and any that follow are written to a temporary file.
+func (a *Align) hold(lineNum int, line string) error {
+	if a.spoolw == nil {
+		a.lines = append(a.lines, line)
+		a.held += int64(len(line)) + 1
+
+		if a.spoolAt <= 0 || a.held <= a.spoolAt {
+			return nil
+		}
+
+		if err := a.createSpool(); err != nil {
+			return fmt.Errorf("align: spooling line %d: %w", lineNum, err)
+		}
+
+		for _, l := range a.lines {
+			if err := a.writeSpool(l); err != nil {
+				return fmt.Errorf("align: spooling line %d: %w", lineNum, err)
+			}
+		}
+		a.lines = nil
+
+		return nil
+	}
+
+	if err := a.writeSpool(line); err != nil {
+		return fmt.Errorf("align: spooling line %d: %w", lineNum, err)
+	}
+	return nil
+}
+
+func (a *Align) createSpool() error {
+	a.spoolMu.Lock()
+	defer a.spoolMu.Unlock()
+
+	if a.closed {
+		return errClosed
+	}
+
+	f, err := os.CreateTemp("", "align-spool-*")
+	if err != nil {
+		return err
+	}
+
+	a.spool = f
+	a.spoolw = bufio.NewWriter(f)
+	return nil
+}
+
+func (a *Align) writeSpool(line string) error {
+	if _, err := a.spoolw.WriteString(line); err != nil {
+		return err
+	}
+	return a.spoolw.WriteByte('\n')
+}
+
+// finishSpool flushes the spooled lines so they can be read back by export.
+func (a *Align) finishSpool() error {
+	if a.spoolw == nil {
+		return nil
+	}
+
+	if err := a.spoolw.Flush(); err != nil {
+		return fmt.Errorf("align: spooling input: %w", err)
+	}
+	a.spoolw = nil
+
+	a.spoolMu.Lock()
+	spool := a.spool
+	a.spoolMu.Unlock()
+	if spool == nil {
+		return fmt.Errorf("align: spooling input: %w", errClosed)
+	}
+	a.seeker, a.offset = spool, 0
+
+	return nil
+}
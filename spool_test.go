@@ -0,0 +1,107 @@
+package align
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+var spoolCases = []struct {
+	threshold int64
+	spooled   bool
+}{
+	{
+		0,
+		false,
+	},
+	{
+		1 << 20,
+		false,
+	},
+	{
+		20,
+		true,
+	},
+	{
+		1,
+		true,
+	},
+}
+
+func TestSpoolThreshold(t *testing.T) {
+	input := "first,middle,last\nJohn,,Doe\n\"Henry, Jr.\",M,Mellencamp"
+	expected := `first        , middle , last       
+John         ,        , Doe        
+"Henry, Jr." , M      , Mellencamp 
+`
+
+	for _, tt := range spoolCases {
+		dir := t.TempDir()
+		t.Setenv("TMPDIR", dir)
+
+		out := &bytes.Buffer{}
+		a := NewAlign(onlyReader{strings.NewReader(input)}, out, comma, TextQualifier{On: true, Qualifier: `"`})
+		a.SpoolThreshold(tt.threshold)
+
+		if err := a.columnLength(); err != nil {
+			t.Fatalf("columnLength() = %v; want nil", err)
+		}
+		if got := a.spool != nil; got != tt.spooled {
+			t.Fatalf("SpoolThreshold(%v) spooled = %v; want %v", tt.threshold, got, tt.spooled)
+		}
+		if tt.spooled && len(a.lines) > 0 {
+			t.Fatalf("SpoolThreshold(%v) held %v lines; want 0", tt.threshold, len(a.lines))
+		}
+		if err := a.export(); err != nil {
+			t.Fatalf("export() = %v; want nil", err)
+		}
+		if err := a.Close(); err != nil {
+			t.Fatalf("Close() = %v; want nil", err)
+		}
+
+		if got := out.String(); got != expected {
+			t.Fatalf("export() = \n%v; want\n%v", got, expected)
+		}
+		if entries, _ := os.ReadDir(dir); len(entries) > 0 {
+			t.Fatalf("Close() left %v in %v", entries[0].Name(), dir)
+		}
+	}
+}
+
+func TestSpoolRemovedOnError(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("TMPDIR", dir)
+
+	input := strings.Repeat("first,middle,last\n", 100)
+	a := NewAlign(onlyReader{strings.NewReader(input)}, &errWriter{n: 10}, comma, TextQualifier{})
+	a.SpoolThreshold(64)
+
+	if err := a.Align(); err == nil {
+		t.Fatalf("Align() = nil; want error")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) > 0 {
+		t.Fatalf("Align() left %v in %v", entries[0].Name(), dir)
+	}
+}
+
+func TestSpoolAfterClose(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("TMPDIR", dir)
+
+	input := strings.Repeat("first,middle,last\n", 100)
+	a := NewAlign(onlyReader{strings.NewReader(input)}, &bytes.Buffer{}, comma, TextQualifier{})
+	a.SpoolThreshold(64)
+
+	// as when interrupted before the spool is created
+	if err := a.Close(); err != nil {
+		t.Fatalf("Close() = %v; want nil", err)
+	}
+	if err := a.Align(); !errors.Is(err, errClosed) {
+		t.Fatalf("Align() = %v; want %v", err, errClosed)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) > 0 {
+		t.Fatalf("Align() after Close() left %v in %v", entries[0].Name(), dir)
+	}
+}
@@ -0,0 +1,139 @@
+package align
+
+// Overflow determines how streaming alignment handles a field that is
+// wider than the column width planned for it.
+type Overflow byte
+
+// Grow, Truncate or Spill Overflow options.
+const (
+	OverflowGrow     Overflow = iota + 1 // widen the column for the field and the lines that follow
+	OverflowTruncate                     // cut the field to the width of the column
+	OverflowSpill                        // write the whole field and shift the rest of its line
+)
+
+// StreamOpts provides configurability for aligning input that is written as it is read,
+// such as the output of tail -f.
+type StreamOpts struct {
+	Lines    int      // number of lines used to plan the column widths; 0 disables streaming
+	Window   bool     // plan the column widths again for every block of Lines lines
+	Overflow Overflow // handling of fields wider than the planned column width; 0 for OverflowGrow
+}
+
+// UpdateStream uses StreamOpts s to update the Align's streaming options.
+// When streaming, the column widths are planned from the first s.Lines lines, after which
+// each line is written and flushed as soon as it is read.  With s.Window set, the widths
+// are planned again for each block of s.Lines lines, and each block is written once it is complete.
+// Columns that did not appear while planning are always grown.
+func (a *Align) UpdateStream(s StreamOpts) {
+	a.streamOpts = s
+}
+
+// stream aligns the input a line or a block of lines at a time.
+func (a *Align) stream() error {
+	surroundingPad := a.surroundingPad()
+	lines := a.streamOpts.Lines
+	a.lines = make([]string, 0, lines)
+
+	var lineNum int
+	var planned bool
+	for a.scanner.Scan() {
+		lineNum++
+		line := a.scanner.Text()
+
+		if planned {
+			switch a.streamOpts.Overflow {
+			case 0, OverflowGrow:
+				a.measure(lineNum, line)
+			default:
+				a.measureNew(line)
+			}
+			a.placeTabs()
+
+			if err := a.exportLine(lineNum, line, surroundingPad); err != nil {
+				return err
+			}
+			if err := a.writer.Flush(); err != nil {
+				return &WriteError{Line: lineNum, Err: err}
+			}
+			continue
+		}
+
+		a.measure(lineNum, line)
+		a.lines = append(a.lines, line)
+		if len(a.lines) < lines {
+			continue
+		}
+
+		if err := a.streamBlock(lineNum-len(a.lines)+1, surroundingPad); err != nil {
+			return err
+		}
+		if a.streamOpts.Window {
+			a.columnCounts = make(map[int]int)
+			a.tabCounts = make(map[int][]int)
+			a.sepCounts = make(map[int]int)
+			a.columnTypes = make(map[int]columnType)
+		} else {
+			planned = true
+		}
+	}
+
+	if err := a.scanner.Err(); err != nil {
+		return a.readError(lineNum+1, err)
+	}
+
+	// the input ended before a complete block was read
+	if err := a.streamBlock(lineNum-len(a.lines)+1, surroundingPad); err != nil {
+		return err
+	}
+
+	if err := a.finishRows(); err != nil {
+		return err
+	}
+	if err := a.writer.Flush(); err != nil {
+		return &WriteError{Err: err}
+	}
+	return nil
+}
+
+// streamBlock writes and flushes the lines held while planning, numbered from first.
+func (a *Align) streamBlock(first int, surroundingPad string) error {
+	if a.inferColumns() {
+		for i, line := range a.lines {
+			a.measure(first+i, line)
+		}
+	}
+	a.placeTabs()
+
+	for i, line := range a.lines {
+		if err := a.exportLine(first+i, line, surroundingPad); err != nil {
+			return err
+		}
+	}
+	a.lines = a.lines[:0]
+
+	if err := a.writer.Flush(); err != nil {
+		return &WriteError{Line: first, Err: err}
+	}
+	return nil
+}
+
+// measureNew records the width of the fields in line that belong to columns
+// that have not been measured yet.
+func (a *Align) measureNew(line string) {
+	words, _ := a.fields(line)
+	for i, word := range words {
+		if _, ok := a.columnCounts[i]; !ok {
+			a.columnCounts[i] = 0
+			a.measureField(i, word)
+		}
+	}
+}
+
+// truncateField cuts s, written at the output column col, to the display width n.
+// s is returned unchanged if n is -1.
+func truncateField(s string, n, col int, w WidthOpts) string {
+	if n < 0 {
+		return s
+	}
+	return w.truncate(s, n, col)
+}
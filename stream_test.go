@@ -0,0 +1,123 @@
+package align
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+var streamCases = []struct {
+	input    string
+	opts     StreamOpts
+	expected string
+}{
+	{
+		"a,b\ndd,e\nlonger,x\nz,zz",
+		StreamOpts{Lines: 2, Overflow: OverflowGrow},
+		"a  , b \ndd , e \nlonger , x \nz      , zz \n",
+	},
+	{
+		"a,b\ndd,e\nlonger,x\nz,zz",
+		StreamOpts{Lines: 2},
+		"a  , b \ndd , e \nlonger , x \nz      , zz \n",
+	},
+	{
+		"a,b\ndd,e\nlonger,x\nz,zz",
+		StreamOpts{Lines: 2, Overflow: OverflowTruncate},
+		"a  , b \ndd , e \nlo , x \nz  , z \n",
+	},
+	{
+		"a,b\ndd,e\nlonger,x\nz,zz",
+		StreamOpts{Lines: 2, Overflow: OverflowSpill},
+		"a  , b \ndd , e \nlonger , x \nz  , zz \n",
+	},
+	{
+		"a,b\ndd,e\nlonger,x\nz,zz\nq",
+		StreamOpts{Lines: 2, Window: true},
+		"a  , b \ndd , e \nlonger , x  \nz      , zz \nq \n",
+	},
+	{
+		"a,b\ndd,e,f",
+		StreamOpts{Lines: 1, Overflow: OverflowTruncate},
+		"a , b \nd , e , f \n",
+	},
+	{
+		"a,b",
+		StreamOpts{Lines: 5},
+		"a , b \n",
+	},
+}
+
+func TestStream(t *testing.T) {
+	for _, tt := range streamCases {
+		out := &bytes.Buffer{}
+		a := NewAlign(strings.NewReader(tt.input), out, comma, TextQualifier{})
+		a.UpdateStream(tt.opts)
+
+		if err := a.Align(); err != nil {
+			t.Fatalf("Align() = %v; want nil", err)
+		}
+		if got := out.String(); got != tt.expected {
+			t.Fatalf("Align() with %+v = %q; want %q", tt.opts, got, tt.expected)
+		}
+	}
+}
+
+// flushCounter counts the writes to it, which happen on each flush of the Align's writer.
+type flushCounter struct {
+	writes int
+}
+
+func (w *flushCounter) Write(p []byte) (int, error) {
+	w.writes++
+	return len(p), nil
+}
+
+func TestStreamFlushesEachLine(t *testing.T) {
+	w := &flushCounter{}
+	a := NewAlign(strings.NewReader("a,b\nc,d\ne,f\ng,h"), w, comma, TextQualifier{})
+	a.UpdateStream(StreamOpts{Lines: 1})
+
+	if err := a.Align(); err != nil {
+		t.Fatalf("Align() = %v; want nil", err)
+	}
+	if w.writes != 4 {
+		t.Fatalf("Align() flushed %v times; want %v", w.writes, 4)
+	}
+}
+
+func TestStreamWindowResets(t *testing.T) {
+	var cases = []struct {
+		input    string
+		setup    func(a *Align)
+		expected string
+	}{
+		{
+			"x,a\ny,bb\nz,1\nw,22",
+			func(a *Align) { a.UpdatePadding(PaddingOpts{Justification: JustifyAuto, Pad: 1}) },
+			"x , a  \ny , bb \nz ,  1 \nw , 22 \n",
+		},
+		{
+			"a := 1\nb := 2\nc = 3\nd = 4",
+			func(a *Align) {
+				a.SplitTokens(":=", "=")
+				a.KeepSeparators(true)
+			},
+			"a := 1 \nb := 2 \nc = 3 \nd = 4 \n",
+		},
+	}
+
+	for _, tt := range cases {
+		out := &bytes.Buffer{}
+		a := NewAlign(strings.NewReader(tt.input), out, ",", TextQualifier{})
+		tt.setup(a)
+		a.UpdateStream(StreamOpts{Lines: 2, Window: true})
+
+		if err := a.Align(); err != nil {
+			t.Fatalf("Align() = %v; want nil", err)
+		}
+		if got := out.String(); got != tt.expected {
+			t.Fatalf("Align(%q) = %q; want %q", tt.input, got, tt.expected)
+		}
+	}
+}
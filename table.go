@@ -0,0 +1,133 @@
+package align
+
+import "strings"
+
+// Style is the set of characters used to draw the borders and rules of a table.
+// The rules are not drawn if Horizontal is empty, and the outer borders are not
+// drawn if Vertical is empty.
+type Style struct {
+	Horizontal string    // line of the top, header and bottom rules
+	Vertical   string    // line between and around the columns
+	Top        [3]string // left corner, junction and right corner of the top rule
+	Middle     [3]string // left end, junction and right end of the header rule
+	Bottom     [3]string // left corner, junction and right corner of the bottom rule
+}
+
+// Table styles.
+var (
+	StyleASCII = Style{
+		Horizontal: "-",
+		Vertical:   "|",
+		Top:        [3]string{"+", "+", "+"},
+		Middle:     [3]string{"+", "+", "+"},
+		Bottom:     [3]string{"+", "+", "+"},
+	}
+	StyleLight = Style{
+		Horizontal: "─",
+		Vertical:   "│",
+		Top:        [3]string{"┌", "┬", "┐"},
+		Middle:     [3]string{"├", "┼", "┤"},
+		Bottom:     [3]string{"└", "┴", "┘"},
+	}
+	StyleHeavy = Style{
+		Horizontal: "━",
+		Vertical:   "┃",
+		Top:        [3]string{"┏", "┳", "┓"},
+		Middle:     [3]string{"┣", "╋", "┫"},
+		Bottom:     [3]string{"┗", "┻", "┛"},
+	}
+	StyleDouble = Style{
+		Horizontal: "═",
+		Vertical:   "║",
+		Top:        [3]string{"╔", "╦", "╗"},
+		Middle:     [3]string{"╠", "╬", "╣"},
+		Bottom:     [3]string{"╚", "╩", "╝"},
+	}
+	StyleRounded = Style{
+		Horizontal: "─",
+		Vertical:   "│",
+		Top:        [3]string{"╭", "┬", "╮"},
+		Middle:     [3]string{"├", "┼", "┤"},
+		Bottom:     [3]string{"╰", "┴", "╯"},
+	}
+	StyleNone = Style{}
+)
+
+// TableStyle sets the Style of the tables written with FormatTable.  The default is StyleASCII.
+// The rules are drawn at the column widths, with PaddingOpts.Pad on either side of each field.
+func (a *Align) TableStyle(s Style) {
+	a.tableStyle = s
+}
+
+// tableRow writes the fields of the line numbered lineNum as a row of a table,
+// after the top rule for the first line and the header rule around the header.
+func (a *Align) tableRow(sb *strings.Builder, lineNum int, words []string) {
+	s := a.tableStyle
+	switch {
+	case a.lastRow == 0:
+		a.tableRule(sb, s.Top)
+	case lineNum != a.lastRow && a.lastRow == a.headerLines():
+		a.tableRule(sb, s.Middle)
+	case a.isHeader(lineNum) && !a.isHeader(a.lastRow):
+		// a repeated header
+		a.tableRule(sb, s.Middle)
+	}
+
+	var row strings.Builder
+	pad := strings.Repeat(string(padchar), a.padOpts.Pad)
+	for i, c := range a.outputColumns() {
+		switch {
+		case i > 0:
+			row.WriteString(pad + s.Vertical + pad)
+		case s.Vertical != "":
+			row.WriteString(s.Vertical + pad)
+		}
+
+		var word string
+		if c < len(words) {
+			word = a.prepare(words[c], c)
+		}
+		row.WriteString(a.justify(word, lineNum, c, a.columnCounts[c]))
+	}
+
+	if s.Vertical != "" {
+		row.WriteString(pad + s.Vertical)
+		sb.WriteString(row.String())
+	} else {
+		// without a border, the padding of the last column is not needed
+		sb.WriteString(strings.TrimRight(row.String(), string(padchar)))
+	}
+	sb.WriteByte('\n')
+}
+
+// tableRule writes a rule across the columns of a table with the corners and junctions in ends.
+func (a *Align) tableRule(sb *strings.Builder, ends [3]string) {
+	s := a.tableStyle
+	if s.Horizontal == "" {
+		return
+	}
+
+	cols := a.outputColumns()
+	if s.Vertical != "" {
+		sb.WriteString(ends[0])
+	}
+	for i, c := range cols {
+		if i > 0 {
+			sb.WriteString(ends[1])
+		}
+		sb.WriteString(a.hline(a.columnCounts[c] + 2*a.padOpts.Pad))
+	}
+	if s.Vertical != "" {
+		sb.WriteString(ends[2])
+	}
+	sb.WriteByte('\n')
+}
+
+// hline returns the table's Horizontal line repeated across n cells.
+func (a *Align) hline(n int) string {
+	w := a.widthOpts.width(a.tableStyle.Horizontal)
+	if w < 1 {
+		w = 1
+	}
+	return strings.Repeat(a.tableStyle.Horizontal, n/w)
+}
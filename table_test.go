@@ -0,0 +1,133 @@
+package align
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+var tableStyleCases = []struct {
+	style    Style
+	expected string
+}{
+	{StyleASCII, `+-------+-----+
+| name  | qty |
++-------+-----+
+| apple |  10 |
+| kiwi  |   5 |
+| fig   |     |
++-------+-----+
+`},
+	{StyleLight, `┌───────┬─────┐
+│ name  │ qty │
+├───────┼─────┤
+│ apple │  10 │
+│ kiwi  │   5 │
+│ fig   │     │
+└───────┴─────┘
+`},
+	{StyleRounded, `╭───────┬─────╮
+│ name  │ qty │
+├───────┼─────┤
+│ apple │  10 │
+│ kiwi  │   5 │
+│ fig   │     │
+╰───────┴─────╯
+`},
+	{StyleNone, `name   qty
+apple   10
+kiwi     5
+fig
+`},
+}
+
+func TestTableStyle(t *testing.T) {
+	input := "name,qty\napple,10\nkiwi,5\nfig"
+
+	for _, tt := range tableStyleCases {
+		out := &bytes.Buffer{}
+		a := NewAlign(strings.NewReader(input), out, ",", TextQualifier{})
+		a.UpdatePadding(PaddingOpts{Justification: JustifyLeft, ColumnOverride: map[int]Justification{2: JustifyRight}, Pad: 1})
+		a.OutputFormat(FormatTable)
+		a.TableStyle(tt.style)
+
+		if err := a.Align(); err != nil {
+			t.Fatalf("Align() = %v; want nil", err)
+		}
+		if got := out.String(); got != tt.expected {
+			t.Fatalf("Align() = \n%v; want\n%v", got, tt.expected)
+		}
+	}
+}
+
+func TestTableWide(t *testing.T) {
+	input := "a,b\nかど,x"
+
+	out := &bytes.Buffer{}
+	a := NewAlign(strings.NewReader(input), out, ",", TextQualifier{})
+	a.OutputFormat(FormatTable)
+	a.TableStyle(StyleDouble)
+	a.FilterColumns([]int{1})
+
+	if err := a.Align(); err != nil {
+		t.Fatalf("Align() = %v; want nil", err)
+	}
+
+	expected := `╔══════╗
+║ a    ║
+╠══════╣
+║ かど ║
+╚══════╝
+`
+	if got := out.String(); got != expected {
+		t.Fatalf("Align() = \n%v; want\n%v", got, expected)
+	}
+}
+
+func TestTableMultiline(t *testing.T) {
+	input := "\"a\nb\",c\nd,e"
+
+	out := &bytes.Buffer{}
+	a := NewAlign(strings.NewReader(input), out, ",", TextQualifier{On: true, Qualifier: `"`, CSV: true, Multiline: true})
+	a.OutputFormat(FormatTable)
+	a.OutputQuoting(QuoteStrip)
+
+	if err := a.Align(); err != nil {
+		t.Fatalf("Align() = %v; want nil", err)
+	}
+
+	expected := `+---+---+
+| a | c |
+| b |   |
++---+---+
+| d | e |
++---+---+
+`
+	if got := out.String(); got != expected {
+		t.Fatalf("Align() = \n%v; want\n%v", got, expected)
+	}
+}
+
+func TestStreamTable(t *testing.T) {
+	input := "h1,h2\na,b\nc,d"
+
+	out := &bytes.Buffer{}
+	a := NewAlign(strings.NewReader(input), out, ",", TextQualifier{})
+	a.OutputFormat(FormatTable)
+	a.UpdateStream(StreamOpts{Lines: 1, Overflow: OverflowGrow})
+
+	if err := a.Align(); err != nil {
+		t.Fatalf("Align() = %v; want nil", err)
+	}
+
+	expected := `+----+----+
+| h1 | h2 |
++----+----+
+| a  | b  |
+| c  | d  |
++----+----+
+`
+	if got := out.String(); got != expected {
+		t.Fatalf("Align() = \n%v; want\n%v", got, expected)
+	}
+}
@@ -0,0 +1,386 @@
+package align
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// WidthOpts provides configurability for measuring the display width of each field.
+// Widths are measured in terminal cells per grapheme cluster, so combining marks,
+// variation selectors, emoji modifiers, ZWJ sequences and regional indicator
+// flags are measured the way a terminal displays them.
+// With ANSI set, CSI sequences (such as SGR colors) and OSC sequences (such as OSC 8 hyperlinks)
+// take up no cells, and are written to the output unchanged.
+// A tab advances to the next tab stop, counted from the output column at which it is written,
+// so a column holding tabs may be wider than its fields measured on their own.
+type WidthOpts struct {
+	EastAsian  bool // East-Asian ambiguous-width characters are 2 cells wide instead of 1
+	TabWidth   int  // distance between tab stops (default: 8)
+	ExpandTabs bool // replace tabs within fields with spaces in the output
+	ANSI       bool // ignore ANSI escape sequences, and reset any color or hyperlink left open at the end of a field
+}
+
+// UpdateWidth uses WidthOpts w to update how the Align measures the display width of each field.
+func (a *Align) UpdateWidth(w WidthOpts) {
+	a.widthOpts = w
+}
+
+var (
+	narrow = &runewidth.Condition{EastAsianWidth: false}
+	wide   = &runewidth.Condition{EastAsianWidth: true}
+)
+
+const (
+	zwj         = '\u200d' // zero width joiner
+	textStyle   = '\ufe0e' // variation selector-15
+	emojiStyle  = '\ufe0f' // variation selector-16
+	keycap      = '\u20e3' // combining enclosing keycap
+	riFirst     = '\U0001f1e6'
+	riLast      = '\U0001f1ff'
+	modFirst    = '\U0001f3fb' // emoji skin tone modifiers
+	modLast     = '\U0001f3ff'
+	tagFirst    = '\U000e0020' // tags used by subdivision flags
+	tagLast     = '\U000e007f'
+	vsFirst     = '\ufe00'
+	vsLast      = '\ufe0f'
+	vsSuppFirst = '\U000e0100'
+	vsSuppLast  = '\U000e01ef'
+)
+
+const defaultTabWidth = 8
+
+// width returns the number of terminal cells used to display s.
+func (w WidthOpts) width(s string) int {
+	return w.widthAt(s, 0)
+}
+
+// widthAt returns the number of terminal cells used to display s, given that col cells precede it.
+func (w WidthOpts) widthAt(s string, col int) int {
+	total := col
+	for len(s) > 0 {
+		n, cells := w.next(s, total)
+		total += cells
+		s = s[n:]
+	}
+	return total - col
+}
+
+// truncate cuts s to at most n cells without splitting a grapheme cluster, given that col cells precede it.
+func (w WidthOpts) truncate(s string, n, col int) string {
+	var total, end int
+	for end < len(s) {
+		size, cells := w.next(s[end:], col+total)
+		if total+cells > n {
+			break
+		}
+		total += cells
+		end += size
+	}
+	return s[:end]
+}
+
+// expandTabs replaces each tab in s with spaces up to the next tab stop, given that col cells precede it.
+func (w WidthOpts) expandTabs(s string, col int) string {
+	if !strings.Contains(s, "\t") {
+		return s
+	}
+
+	var sb strings.Builder
+	total := col
+	for len(s) > 0 {
+		n, cells := w.next(s, total)
+		if s[0] == '\t' {
+			trailingPad(&sb, cells)
+		} else {
+			sb.WriteString(s[:n])
+		}
+		total += cells
+		s = s[n:]
+	}
+	return sb.String()
+}
+
+// tabWidth returns the distance between tab stops.
+func (w WidthOpts) tabWidth() int {
+	if w.TabWidth <= 0 {
+		return defaultTabWidth
+	}
+	return w.TabWidth
+}
+
+// next returns the length in bytes of the grapheme cluster at the start of s and
+// the number of cells used to display it, given that col cells precede it.
+func (w WidthOpts) next(s string, col int) (size, cells int) {
+	if w.ANSI {
+		if n := escapeLen(s); n > 0 {
+			return n, 0
+		}
+	}
+	if s[0] == '\t' {
+		tab := w.tabWidth()
+		return 1, tab - col%tab
+	}
+	return w.cluster(s)
+}
+
+// cluster returns the length in bytes of the grapheme cluster at the start of s
+// and the number of cells used to display it.
+func (w WidthOpts) cluster(s string) (size, cells int) {
+	cond := narrow
+	if w.EastAsian {
+		cond = wide
+	}
+
+	r, size := utf8.DecodeRuneInString(s)
+	cells = cond.RuneWidth(r)
+
+	if isRegionalIndicator(r) {
+		// a pair of regional indicators is a single flag
+		if next, n := utf8.DecodeRuneInString(s[size:]); isRegionalIndicator(next) {
+			return size + n, 2
+		}
+		return size, cells
+	}
+
+	for size < len(s) {
+		next, n := utf8.DecodeRuneInString(s[size:])
+
+		switch {
+		case next == emojiStyle || next == keycap:
+			cells = 2
+		case next == textStyle:
+			cells = 1
+		case next == zwj:
+			// the joined character is displayed as part of this cluster
+			size += n
+			if size < len(s) {
+				_, n = utf8.DecodeRuneInString(s[size:])
+			} else {
+				n = 0
+			}
+		case isExtend(next):
+		default:
+			return size, cells
+		}
+		size += n
+	}
+	return size, cells
+}
+
+// isExtend reports whether r extends the grapheme cluster before it without
+// taking up any cells of its own.
+func isExtend(r rune) bool {
+	switch {
+	case r >= modFirst && r <= modLast,
+		r >= vsFirst && r <= vsLast,
+		r >= vsSuppFirst && r <= vsSuppLast,
+		r >= tagFirst && r <= tagLast:
+		return true
+	}
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc)
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= riFirst && r <= riLast
+}
+
+const (
+	esc       = '\x1b'
+	bel       = '\a'
+	sgrReset  = "\x1b[0m"
+	linkReset = "\x1b]8;;\x1b\\"
+)
+
+// escapeLen returns the length of the ANSI escape sequence at the start of s, or 0 if there is none.
+// An unterminated sequence runs to the end of s.
+func escapeLen(s string) int {
+	if len(s) < 2 || s[0] != esc {
+		return 0
+	}
+
+	switch s[1] {
+	case '[':
+		// CSI: parameter and intermediate bytes followed by a final byte
+		for i := 2; i < len(s); i++ {
+			if s[i] >= 0x40 && s[i] <= 0x7e {
+				return i + 1
+			}
+		}
+		return len(s)
+	case ']':
+		// OSC: terminated by BEL or ST (ESC \)
+		for i := 2; i < len(s); i++ {
+			if s[i] == bel {
+				return i + 1
+			}
+			if s[i] == esc && i+1 < len(s) && s[i+1] == '\\' {
+				return i + 2
+			}
+		}
+		return len(s)
+	}
+	return 2
+}
+
+// closeEscapes appends the sequences to reset any SGR attributes or OSC 8 hyperlink
+// that are still in effect at the end of s, so that they do not apply to its padding.
+func closeEscapes(s string) string {
+	if !strings.ContainsRune(s, esc) {
+		return s
+	}
+
+	var sgrOpen, linkOpen bool
+	for i := 0; i < len(s); {
+		n := escapeLen(s[i:])
+		if n == 0 {
+			i++
+			continue
+		}
+
+		seq := s[i : i+n]
+		switch {
+		case strings.HasPrefix(seq, "\x1b[") && strings.HasSuffix(seq, "m"):
+			// attributes stay in effect unless the last parameters reset them
+			sgrOpen = false
+			params := strings.Split(seq[2:len(seq)-1], ";")
+			for k := 0; k < len(params); k++ {
+				p := params[k]
+				sgrOpen = p != "" && p != "0"
+				if (p == "38" || p == "48" || p == "58") && k+1 < len(params) {
+					// the arguments of a 256 color or RGB color are not attributes of their own
+					switch params[k+1] {
+					case "5":
+						k += 2
+					case "2":
+						k += 4
+					}
+				}
+			}
+		case strings.HasPrefix(seq, "\x1b]8;"):
+			// OSC 8 ; params ; URI, where an empty URI closes the hyperlink
+			parts := strings.SplitN(strings.TrimRight(seq, "\a\x1b\\"), ";", 3)
+			linkOpen = len(parts) == 3 && parts[2] != ""
+		}
+		i += n
+	}
+
+	if sgrOpen {
+		s += sgrReset
+	}
+	if linkOpen {
+		s += linkReset
+	}
+	return s
+}
+
+// measureField updates the width of the column numbered columnNum with word.  The width of a word containing
+// tabs depends on the output column at which it is written, so it is kept for each position between
+// tab stops until placeTabs knows where the column starts.
+func (a *Align) measureField(columnNum int, word string) {
+	if !strings.Contains(word, "\t") {
+		if temp := cellWidth(word, 0, a.widthOpts); temp > a.columnCounts[columnNum] {
+			a.columnCounts[columnNum] = temp
+		}
+		return
+	}
+
+	if _, ok := a.columnCounts[columnNum]; !ok {
+		a.columnCounts[columnNum] = 0
+	}
+	counts, ok := a.tabCounts[columnNum]
+	if !ok {
+		counts = make([]int, a.widthOpts.tabWidth())
+		a.tabCounts[columnNum] = counts
+	}
+	for col := range counts {
+		if temp := cellWidth(word, col, a.widthOpts); temp > counts[col] {
+			counts[col] = temp
+		}
+	}
+}
+
+// placeTabs widens each column that has fields containing tabs to fit them at the output column
+// where the column starts, and records where each column starts.
+func (a *Align) placeTabs() {
+	if len(a.tabCounts) == 0 {
+		return
+	}
+
+	tab := a.widthOpts.tabWidth()
+	a.starts = make(map[int]int, len(a.columnCounts))
+	a.eachColumn(func(columnNum, start int) {
+		a.starts[columnNum] = start
+		if counts, ok := a.tabCounts[columnNum]; ok && counts[start%tab] > a.columnCounts[columnNum] {
+			a.columnCounts[columnNum] = counts[start%tab]
+		}
+	})
+}
+
+// eachColumn calls fn with the number of each column written to the output, in order, and the output
+// column at which its fields start.  Each start follows from the widths of the columns before it,
+// so fn may widen its column.
+func (a *Align) eachColumn(fn func(columnNum, start int)) {
+	pad := a.padOpts.Pad
+	if pad < 0 {
+		pad = 0
+	}
+
+	var start int
+	switch a.format {
+	case FormatTable:
+		v := a.widthOpts.width(a.tableStyle.Vertical)
+		if v > 0 {
+			start = v + pad
+		}
+		for i, c := range a.outputColumns() {
+			if i > 0 {
+				start += pad + v + pad
+			}
+			fn(c, start)
+			start += a.columnCounts[c]
+		}
+	case FormatMarkdown:
+		start = 1 + pad
+		for _, c := range a.outputColumns() {
+			fn(c, start)
+			start += a.markdownWidth(c) + pad + 1 + pad
+		}
+	case FormatHTML:
+		for _, c := range a.outputColumns() {
+			fn(c, 0)
+		}
+	default:
+		_, seq := a.delim.(sequence)
+		var written bool
+		for c := 0; c < len(a.columnCounts); c++ {
+			if a.filterLen > 0 && !contains(a.filter, c+1) {
+				continue
+			}
+
+			if written {
+				if seq {
+					start += len(a.boundaryPad(c - 1))
+				} else {
+					start += pad
+				}
+			}
+			written = true
+
+			fn(c, start)
+			start += a.columnCounts[c]
+			if seq {
+				start += len(a.boundaryPad(c))
+			} else {
+				start += pad
+			}
+			if a.keepSeps {
+				start += a.sepCounts[c]
+			} else {
+				start += a.widthOpts.width(a.outputSep(c, nil))
+			}
+		}
+	}
+}
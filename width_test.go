@@ -0,0 +1,255 @@
+package align
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const (
+	coder = "\U0001F469\u200d\U0001F4BB" // woman technologist ZWJ sequence
+	japan = "\U0001F1EF\U0001F1F5"       // regional indicator flag
+)
+
+var widthCases = []struct {
+	input     string
+	eastAsian bool
+	expected  int
+}{
+	{"abc", false, 3},
+	{"Luü", false, 3},
+	{"Lu\u0308", false, 2},             // combining diaeresis
+	{"かど", false, 4},                   // wide characters
+	{"\U0001F600", false, 2},           // emoji
+	{"\U0001F44D\U0001F3FD", false, 2}, // emoji with skin tone modifier
+	{coder, false, 2},                  // ZWJ sequence
+	{"\U0001F468\u200d\U0001F469\u200d\U0001F467\u200d\U0001F466", false, 2}, // family ZWJ sequence
+	{"❤", false, 1},       // text presentation by default
+	{"❤\ufe0f", false, 2}, // emoji presentation selector
+	{japan, false, 2},
+	{japan + "\U0001F1FA", false, 4}, // flag and a lone regional indicator
+	{"1\ufe0f\u20e3", false, 2},      // keycap sequence
+	{"§①", false, 2},
+	{"§①", true, 4}, // ambiguous width characters
+}
+
+func TestWidth(t *testing.T) {
+	for _, tt := range widthCases {
+		got := WidthOpts{EastAsian: tt.eastAsian}.width(tt.input)
+		if got != tt.expected {
+			t.Fatalf("width(%q) with EastAsian %v = %v; want %v", tt.input, tt.eastAsian, got, tt.expected)
+		}
+	}
+}
+
+var truncateCases = []struct {
+	input    string
+	n        int
+	expected string
+}{
+	{"abcdef", 3, "abc"},
+	{"かどや", 3, "か"},
+	{coder + "ab", 1, ""},
+	{coder + "ab", 3, coder + "a"},
+	{"Lu\u0308x", 2, "Lu\u0308"},
+}
+
+func TestTruncate(t *testing.T) {
+	for _, tt := range truncateCases {
+		if got := (WidthOpts{}).truncate(tt.input, tt.n, 0); got != tt.expected {
+			t.Fatalf("truncate(%q, %v) = %q; want %q", tt.input, tt.n, got, tt.expected)
+		}
+	}
+}
+
+func TestExportDisplayWidth(t *testing.T) {
+	input := "name,flag\nJapan," + japan + "\nかど," + coder + "\nSo§,ok"
+
+	var cases = []struct {
+		opts     WidthOpts
+		expected string
+	}{
+		{
+			WidthOpts{},
+			"name  , flag \nJapan , " + japan + "   \nかど  , " + coder + "   \nSo§   , ok   \n",
+		},
+		{
+			WidthOpts{EastAsian: true},
+			"name  , flag \nJapan , " + japan + "   \nかど  , " + coder + "   \nSo§  , ok   \n",
+		},
+	}
+
+	for _, tt := range cases {
+		out := &bytes.Buffer{}
+		a := NewAlign(strings.NewReader(input), out, comma, TextQualifier{})
+		a.UpdateWidth(tt.opts)
+
+		if err := a.Align(); err != nil {
+			t.Fatalf("Align() = %v; want nil", err)
+		}
+		if got := out.String(); got != tt.expected {
+			t.Fatalf("Align() with %+v = \n%v; want\n%v", tt.opts, got, tt.expected)
+		}
+	}
+}
+
+var tabWidthCases = []struct {
+	input    string
+	tabWidth int
+	expected int
+}{
+	{"\tx", 0, 9},
+	{"\tx", 4, 5},
+	{"ab\tx", 4, 5},
+	{"abcd\tx", 4, 9},
+	{"\t\tx", 2, 5},
+	{"か\tx", 4, 5},
+}
+
+func TestTabWidth(t *testing.T) {
+	for _, tt := range tabWidthCases {
+		w := WidthOpts{TabWidth: tt.tabWidth}
+		if got := w.width(tt.input); got != tt.expected {
+			t.Fatalf("width(%q) with TabWidth %v = %v; want %v", tt.input, tt.tabWidth, got, tt.expected)
+		}
+		if got := w.width(w.expandTabs(tt.input, 0)); got != tt.expected {
+			t.Fatalf("width(expandTabs(%q)) with TabWidth %v = %v; want %v", tt.input, tt.tabWidth, got, tt.expected)
+		}
+	}
+}
+
+func TestExportTabs(t *testing.T) {
+	input := "\tx = 1\n\tlonger = 2\nfoo\t= 3"
+
+	var cases = []struct {
+		opts     WidthOpts
+		expected string
+	}{
+		{
+			WidthOpts{TabWidth: 4},
+			"\tx      = 1\n\tlonger = 2\nfoo\t       = 3\n",
+		},
+		{
+			WidthOpts{TabWidth: 4, ExpandTabs: true},
+			"    x      = 1\n    longer = 2\nfoo        = 3\n",
+		},
+	}
+
+	for _, tt := range cases {
+		out := &bytes.Buffer{}
+		a := NewAlign(strings.NewReader(input), out, "=", TextQualifier{})
+		a.UpdatePadding(PaddingOpts{Justification: JustifyLeft, Pad: 0})
+		a.UpdateWidth(tt.opts)
+
+		if err := a.Align(); err != nil {
+			t.Fatalf("Align() = %v; want nil", err)
+		}
+		if got := out.String(); got != tt.expected {
+			t.Fatalf("Align() with %+v = %q; want %q", tt.opts, got, tt.expected)
+		}
+	}
+}
+
+var tabColumnCases = []struct {
+	input    string
+	just     Justification
+	format   Format
+	expected string
+}{
+	{
+		"abcdefghij=x\ty=1\na=q=2",
+		JustifyLeft,
+		FormatText,
+		"abcdefghij = x\ty = 1 \na          = q    = 2 \n",
+	},
+	{
+		"abcdefghij=x\ty=1\na=q=2\nbb=x\t\tz=3",
+		JustifyRight,
+		FormatText,
+		"abcdefghij =         x\ty = 1 \n         a =            q = 2 \n        bb = x\t\tz = 3 \n",
+	},
+	{
+		"abcdefghij=x\ty=1\na=q=2",
+		JustifyLeft,
+		FormatTable,
+		"+------------+------------+---+\n| abcdefghij | x\ty | 1 |\n+------------+------------+---+\n| a          | q          | 2 |\n+------------+------------+---+\n",
+	},
+}
+
+func TestExportTabColumns(t *testing.T) {
+	for _, tt := range tabColumnCases {
+		out := &bytes.Buffer{}
+		a := NewAlign(strings.NewReader(tt.input), out, "=", TextQualifier{})
+		a.UpdatePadding(PaddingOpts{Justification: tt.just, Pad: 1})
+		a.OutputFormat(tt.format)
+
+		if err := a.Align(); err != nil {
+			t.Fatalf("Align() = %v; want nil", err)
+		}
+		if got := out.String(); got != tt.expected {
+			t.Fatalf("Align(%q) = %q; want %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+var ansiWidthCases = []struct {
+	input    string
+	expected int
+}{
+	{"\x1b[31mred\x1b[0m", 3},
+	{"\x1b[1;38;5;208mbold\x1b[m", 4},
+	{"\x1b]8;;https://example.com\x1b\\link\x1b]8;;\x1b\\", 4},
+	{"\x1b]8;;https://example.com\alink\x1b]8;;\a", 4},
+	{"\x1b[01;34mか\x1b[K", 2},
+	{"\x1b[31", 0}, // unterminated
+}
+
+func TestANSIWidth(t *testing.T) {
+	for _, tt := range ansiWidthCases {
+		if got := (WidthOpts{ANSI: true}).width(tt.input); got != tt.expected {
+			t.Fatalf("width(%q) with ANSI = %v; want %v", tt.input, got, tt.expected)
+		}
+	}
+}
+
+var closeEscapesCases = []struct {
+	input    string
+	expected string
+}{
+	{"plain", "plain"},
+	{"\x1b[31mred\x1b[0m", "\x1b[31mred\x1b[0m"},
+	{"\x1b[31mred", "\x1b[31mred\x1b[0m"},
+	{"\x1b[0;31mred", "\x1b[0;31mred\x1b[0m"},
+	{"\x1b[31;0mred", "\x1b[31;0mred"},
+	{"\x1b[48;5;0mblack", "\x1b[48;5;0mblack\x1b[0m"},
+	{"\x1b[38;2;255;0;0mred", "\x1b[38;2;255;0;0mred\x1b[0m"},
+	{"\x1b[1;38;5;0;0mplain", "\x1b[1;38;5;0;0mplain"},
+	{"\x1b[1mbold\x1b[m", "\x1b[1mbold\x1b[m"},
+	{"\x1b]8;;https://example.com\x1b\\link", "\x1b]8;;https://example.com\x1b\\link\x1b]8;;\x1b\\"},
+	{"\x1b]8;id=1;https://example.com\alink\x1b]8;;\a", "\x1b]8;id=1;https://example.com\alink\x1b]8;;\a"},
+}
+
+func TestCloseEscapes(t *testing.T) {
+	for _, tt := range closeEscapesCases {
+		if got := closeEscapes(tt.input); got != tt.expected {
+			t.Fatalf("closeEscapes(%q) = %q; want %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestExportANSI(t *testing.T) {
+	input := "\x1b[01;34mdir\x1b[0m,4096\nfile.go,\x1b[31m12"
+
+	out := &bytes.Buffer{}
+	a := NewAlign(strings.NewReader(input), out, comma, TextQualifier{})
+	a.UpdateWidth(WidthOpts{ANSI: true})
+
+	if err := a.Align(); err != nil {
+		t.Fatalf("Align() = %v; want nil", err)
+	}
+
+	expected := "\x1b[01;34mdir\x1b[0m     , 4096 \nfile.go , \x1b[31m12\x1b[0m   \n"
+	if got := out.String(); got != expected {
+		t.Fatalf("Align() = %q; want %q", got, expected)
+	}
+}